@@ -0,0 +1,152 @@
+/*
+* File Name:	batch.go
+* Description:  并发批量处理图片
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-04
+ */
+
+package youtu
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	//DefaultBatchConcurrency Batch默认的并发请求数
+	DefaultBatchConcurrency = 4
+)
+
+//BatchOp 批量处理中对每张图片执行的操作，y已经绑定了Batch的ctx
+type BatchOp func(y *Youtu, image Image) (interface{}, error)
+
+//BatchDetectFace 对每张图片进行人脸检测，结果为DetectFaceRsp
+func BatchDetectFace(isBigFace bool) BatchOp {
+	return func(y *Youtu, image Image) (interface{}, error) {
+		return y.DetectFace(image, isBigFace)
+	}
+}
+
+//BatchFaceShape 对每张图片进行五官定位，结果为FaceShapeRsp
+func BatchFaceShape(isBigFace bool) BatchOp {
+	return func(y *Youtu, image Image) (interface{}, error) {
+		return y.FaceShape(image, isBigFace)
+	}
+}
+
+//BatchFaceIdentify 在组groupID中识别每张图片中的人脸，结果为FaceIdentifyRsp
+func BatchFaceIdentify(groupID string) BatchOp {
+	return func(y *Youtu, image Image) (interface{}, error) {
+		return y.FaceIdentify(groupID, image)
+	}
+}
+
+//BatchOptions 批量处理选项
+type BatchOptions struct {
+	Concurrency int                    //同时进行的请求数，小于等于0时使用DefaultBatchConcurrency
+	Ordered     bool                   //按输入顺序输出结果，否则按完成顺序输出
+	Progress    func(done, failed int) //每输出一个结果后调用，done为已输出的数量，failed为其中失败的数量
+}
+
+//BatchResult 批量处理中一张图片的结果
+type BatchResult struct {
+	Index  int         //图片在输入中的序号，从0开始
+	Image  Image       //输入的图片
+	Result interface{} //BatchOp的结果，失败时可能为nil
+	Err    error       //BatchOp返回的错误
+}
+
+//Batch 从images读取图片并发执行op，结果从返回的channel输出。
+//images关闭并且所有图片处理完成后，或者ctx取消后返回的channel被关闭；
+//调用者需要读取结果直到channel关闭或者取消ctx。请求遵守SetRateLimit设置的频率限制
+func (y *Youtu) Batch(ctx context.Context, images <-chan Image, op BatchOp, opt BatchOptions) <-chan BatchResult {
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	yc := y.WithContext(ctx)
+	jobs := make(chan BatchResult)
+	results := make(chan BatchResult)
+	out := make(chan BatchResult)
+
+	go func() {
+		defer close(jobs)
+		for i := 0; ; i++ {
+			var job BatchResult
+			select {
+			case image, ok := <-images:
+				if !ok {
+					return
+				}
+				job = BatchResult{Index: i, Image: image}
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.Result, job.Err = op(yc, job.Image)
+				results <- job
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	go func() {
+		defer close(out)
+		done, failed := 0, 0
+		canceled := false
+		emit := func(r BatchResult) {
+			if canceled {
+				return
+			}
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				canceled = true
+				return
+			}
+			done++
+			if r.Err != nil {
+				failed++
+			}
+			if opt.Progress != nil {
+				opt.Progress(done, failed)
+			}
+		}
+		//ctx取消后继续读取results直到关闭，避免阻塞正在执行的请求
+		pending := make(map[int]BatchResult)
+		next := 0
+		for r := range results {
+			if !opt.Ordered {
+				emit(r)
+				continue
+			}
+			pending[r.Index] = r
+			for {
+				p, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+				emit(p)
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,131 @@
+/*
+* File Name:	batch_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-04
+ */
+
+package youtu
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//newBatchYoutu 返回随机延迟响应detectface的Youtu，n记录请求数
+func newBatchYoutu(n *int32) (*Youtu, func()) {
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		atomic.AddInt32(n, 1)
+		time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+		w.Write([]byte(`{"face":[{"face_id":"f1"}]}`))
+	})
+	return y, ts.Close
+}
+
+func batchImages(n int, empty int) <-chan Image {
+	images := make(chan Image)
+	go func() {
+		defer close(images)
+		for i := 0; i < n; i++ {
+			if i == empty {
+				images <- Image{}
+				continue
+			}
+			images <- ImageFile(testDataDir + "imageA.jpg")
+		}
+	}()
+	return images
+}
+
+func TestBatchOrdered(t *testing.T) {
+	var requests int32
+	y, done := newBatchYoutu(&requests)
+	defer done()
+	var progress, failed int
+	out := y.Batch(context.Background(), batchImages(20, 7), BatchDetectFace(false), BatchOptions{
+		Concurrency: 4,
+		Ordered:     true,
+		Progress:    func(d, f int) { progress, failed = d, f },
+	})
+	i := 0
+	for r := range out {
+		if r.Index != i {
+			t.Errorf("result %d has index %d\n", i, r.Index)
+		}
+		if i == 7 {
+			if r.Err != ErrEmptyImage {
+				t.Errorf("result 7 err = %v\n", r.Err)
+			}
+		} else if rsp, ok := r.Result.(DetectFaceRsp); !ok || r.Err != nil || len(rsp.Face) != 1 {
+			t.Errorf("result %d: %#v\n", i, r)
+		}
+		i++
+	}
+	if i != 20 || progress != 20 || failed != 1 || requests != 19 {
+		t.Errorf("results = %d, progress = %d/%d, requests = %d\n", i, progress, failed, requests)
+	}
+}
+
+func TestBatchUnordered(t *testing.T) {
+	var requests int32
+	y, done := newBatchYoutu(&requests)
+	defer done()
+	seen := make(map[int]bool)
+	for r := range y.Batch(context.Background(), batchImages(30, -1), BatchDetectFace(false), BatchOptions{Concurrency: 8}) {
+		if r.Err != nil || seen[r.Index] {
+			t.Errorf("result: %#v\n", r)
+		}
+		seen[r.Index] = true
+	}
+	if len(seen) != 30 {
+		t.Errorf("results = %d\n", len(seen))
+	}
+}
+
+func TestBatchCancel(t *testing.T) {
+	var requests int32
+	y, done := newBatchYoutu(&requests)
+	defer done()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	images := make(chan Image) //不关闭，只能通过取消结束
+	go func() {
+		for {
+			select {
+			case images <- ImageFile(testDataDir + "imageA.jpg"):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	n := 0
+	for range y.Batch(ctx, images, BatchDetectFace(false), BatchOptions{}) {
+		if n++; n == 5 {
+			cancel()
+		}
+	}
+	if n < 5 {
+		t.Errorf("results = %d\n", n)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var requests int32
+	y, done := newBatchYoutu(&requests)
+	defer done()
+	y.SetRateLimit(100)
+	start := time.Now()
+	for range y.Batch(context.Background(), batchImages(6, -1), BatchDetectFace(false), BatchOptions{Concurrency: 6}) {
+	}
+	//6个请求至少间隔5个10ms
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("6 requests at 100 qps took %s\n", d)
+	}
+}
@@ -0,0 +1,378 @@
+/*
+* File Name:	cache.go
+* Description:  按图片内容缓存接口返回
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-06
+ */
+
+package youtu
+
+import (
+	"container/list"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+)
+
+//Cache 接口返回的缓存，value为接口返回的JSON。ttl为0表示不过期
+type Cache interface {
+	Get(key string) (value []byte, ok bool)
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+//cacheKind 接口返回是否可以缓存
+type cacheKind int
+
+const (
+	//cacheNone 不缓存
+	cacheNone cacheKind = iota
+	//cacheStatic 返回只由请求决定
+	cacheStatic
+	//cacheIdentity 返回与人脸库有关，人脸库修改后失效
+	cacheIdentity
+)
+
+//cacheEndpoints 可以缓存的接口
+var cacheEndpoints = map[string]cacheKind{
+	serviceFace + "/detectface":        cacheStatic,
+	serviceFace + "/faceshape":         cacheStatic,
+	serviceFace + "/facecompare":       cacheStatic,
+	serviceFace + "/faceverify":        cacheIdentity,
+	serviceFace + "/faceidentify":      cacheIdentity,
+	serviceFace + "/multifaceidentify": cacheIdentity,
+	serviceImage + "/imagetag":         cacheStatic,
+	serviceImage + "/imageporn":        cacheStatic,
+	serviceImage + "/fuzzydetect":      cacheStatic,
+	serviceImage + "/fooddetect":       cacheStatic,
+	serviceOCR + "/idcardocr":          cacheStatic,
+	serviceOCR + "/namecardocr":        cacheStatic,
+	serviceOCR + "/generalocr":         cacheStatic,
+	serviceOCR + "/driverlicenseocr":   cacheStatic,
+	serviceOCR + "/creditcardocr":      cacheStatic,
+	serviceOCR + "/bizlicenseocr":      cacheStatic,
+	serviceOCR + "/plateocr":           cacheStatic,
+	serviceCar + "/carclassify":        cacheStatic,
+}
+
+//galleryEndpoints 修改人脸库的接口，成功后识别类接口的缓存失效
+var galleryEndpoints = map[string]bool{
+	serviceFace + "/newperson": true,
+	serviceFace + "/delperson": true,
+	serviceFace + "/addface":   true,
+	serviceFace + "/delface":   true,
+	serviceFace + "/setinfo":   true,
+}
+
+//cacheGenerationKey 缓存中保存人脸库版本的key
+const cacheGenerationKey = "gallery-generation"
+
+//CachePolicy 缓存的有效期。
+//人脸库的版本保存在缓存中，通过共享同一缓存的客户端修改人脸库后识别接口的缓存立即失效(包括重启后的进程)，
+//其他客户端的修改需要调用InvalidateGallery或等待过期
+type CachePolicy struct {
+	TTL         time.Duration //检测、OCR等接口的有效期，0表示不过期
+	IdentityTTL time.Duration //FaceIdentify等识别接口的有效期，0表示不缓存识别接口
+}
+
+//responseCache 客户端使用的缓存，WithContext返回的副本共享同一个responseCache
+type responseCache struct {
+	cache  Cache
+	policy CachePolicy
+	mu     sync.Mutex
+	pruned time.Time //上次清理过期缓存的时间
+}
+
+//SetCache 设置接口返回的缓存，nil表示不缓存(默认)。
+//缓存的key由接口、请求参数和图片内容的SHA-256组成；io.Reader输入只能读取一次，不会被缓存，
+//url输入按url缓存。只缓存成功(状态码为0)的返回
+func (y *Youtu) SetCache(c Cache, policy CachePolicy) {
+	if c == nil {
+		y.cache = nil
+		return
+	}
+	y.cache = &responseCache{cache: c, policy: policy}
+}
+
+//InvalidateGallery 使识别类接口的缓存失效，用于人脸库被其他客户端修改之后
+func (y *Youtu) InvalidateGallery() {
+	if y.cache != nil {
+		y.cache.bump()
+	}
+}
+
+//generation 返回缓存中保存的人脸库版本。缓存中没有时(第一次使用或被淘汰)生成新的版本，
+//之前的识别结果不再命中
+func (c *responseCache) generation() string {
+	if g, ok := c.cache.Get(cacheGenerationKey); ok && len(g) > 0 {
+		return string(g)
+	}
+	return c.bump()
+}
+
+//bump 生成随机的新版本，多个客户端同时修改时不会得到相同的版本
+func (c *responseCache) bump() string {
+	var b [8]byte
+	rand.Read(b[:])
+	g := hex.EncodeToString(b[:])
+	c.cache.Set(cacheGenerationKey, []byte(g), 0)
+	c.prune()
+	return g
+}
+
+//pruner 可以删除过期数据的缓存，如DiskCache
+type pruner interface {
+	Prune() error
+}
+
+//prune 人脸库修改后旧版本的识别结果不会再被读取，每个IdentityTTL最多清理一次过期的缓存
+func (c *responseCache) prune() {
+	p, ok := c.cache.(pruner)
+	if !ok || c.policy.IdentityTTL == 0 {
+		return
+	}
+	c.mu.Lock()
+	if time.Since(c.pruned) < c.policy.IdentityTTL {
+		c.mu.Unlock()
+		return
+	}
+	c.pruned = time.Now()
+	c.mu.Unlock()
+	p.Prune()
+}
+
+//key 返回请求的缓存key和有效期，不能缓存时返回空
+func (c *responseCache) key(service, ifname string, req interface{}) (key string, ttl time.Duration, err error) {
+	if c == nil {
+		return
+	}
+	endpoint := service + "/" + ifname
+	switch cacheEndpoints[endpoint] {
+	case cacheStatic:
+		ttl = c.policy.TTL
+	case cacheIdentity:
+		if c.policy.IdentityTTL == 0 {
+			return
+		}
+		ttl = c.policy.IdentityTTL
+		endpoint = fmt.Sprintf("%s@%s", endpoint, c.generation())
+	default:
+		return
+	}
+	key, err = requestKey(endpoint, req)
+	return
+}
+
+//requestKey 计算接口和请求参数的SHA-256，请求中有io.Reader输入时返回空
+func requestKey(endpoint string, req interface{}) (string, error) {
+	if hasReader(reflect.ValueOf(req)) {
+		return "", nil
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", endpoint)
+	//图片以base64写入，与对图片数据计算哈希等价
+	if err := encodeJSON(h, req); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *responseCache) get(key string) ([]byte, bool) {
+	if c == nil || key == "" {
+		return nil, false
+	}
+	return c.cache.Get(key)
+}
+
+func (c *responseCache) set(key string, body []byte, ttl time.Duration) {
+	if c == nil || key == "" {
+		return
+	}
+	c.cache.Set(key, body, ttl)
+}
+
+//update 人脸库修改成功后更新版本
+func (c *responseCache) update(service, ifname string) {
+	if c != nil && galleryEndpoints[service+"/"+ifname] {
+		c.bump()
+	}
+}
+
+//hasReader 请求中是否有io.Reader输入
+func hasReader(v reflect.Value) bool {
+	switch {
+	case !v.IsValid():
+		return false
+	case v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface:
+		return !v.IsNil() && hasReader(v.Elem())
+	case v.Type().Implements(payloadType):
+		return v.Interface().(payload).isReader()
+	case v.Kind() == reflect.Struct:
+		for _, f := range structFields(v.Type()) {
+			if fv, ok := fieldByIndex(v, f.index); ok && hasReader(fv) {
+				return true
+			}
+		}
+	case v.Kind() == reflect.Map:
+		for _, k := range v.MapKeys() {
+			if hasReader(v.MapIndex(k)) {
+				return true
+			}
+		}
+	case isList(v):
+		for i := 0; i < v.Len(); i++ {
+			if hasReader(v.Index(i)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//memoryCache 内存中的LRU缓存
+type memoryCache struct {
+	mu      sync.Mutex
+	size    int
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+//NewMemoryCache 创建最多保存size个返回的内存LRU缓存
+func NewMemoryCache(size int) Cache {
+	return &memoryCache{
+		size:    size,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *memoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*memoryEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.ll.Remove(e)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	return entry.value, true
+}
+
+func (c *memoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	value = append([]byte(nil), value...)
+	if e, ok := c.entries[key]; ok {
+		e.Value = &memoryEntry{key, value, expires}
+		c.ll.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.ll.PushFront(&memoryEntry{key, value, expires})
+	for c.size > 0 && c.ll.Len() > c.size {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.entries, e.Value.(*memoryEntry).key)
+	}
+}
+
+//DiskCache 以文件保存返回的缓存，每个key一个文件，过期的文件在读取时或Prune时删除。
+//设置了IdentityTTL时，修改人脸库后会定期调用Prune删除旧版本的识别结果
+type DiskCache string
+
+//Get 读取key对应的返回
+func (d DiskCache) Get(key string) ([]byte, bool) {
+	path := filepath.Join(string(d), key)
+	data, err := ioutil.ReadFile(path)
+	if err != nil || len(data) < 8 {
+		return nil, false
+	}
+	if diskExpired(data) {
+		os.Remove(path)
+		return nil, false
+	}
+	return data[8:], true
+}
+
+//diskExpired 文件开头8个字节的过期时间是否已过
+func diskExpired(head []byte) bool {
+	expires := int64(binary.BigEndian.Uint64(head))
+	return expires != 0 && time.Now().UnixNano() > expires
+}
+
+//Prune 删除所有过期的文件
+func (d DiskCache) Prune() error {
+	files, err := ioutil.ReadDir(string(d))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, fi := range files {
+		if fi.IsDir() {
+			continue
+		}
+		path := filepath.Join(string(d), fi.Name())
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		var head [8]byte
+		_, err = io.ReadFull(f, head[:])
+		f.Close()
+		if err == nil && diskExpired(head[:]) {
+			os.Remove(path)
+		}
+	}
+	return nil
+}
+
+//Set 保存key对应的返回，文件开头8个字节为过期时间
+func (d DiskCache) Set(key string, value []byte, ttl time.Duration) {
+	var expires int64
+	if ttl > 0 {
+		expires = time.Now().Add(ttl).UnixNano()
+	}
+	data := make([]byte, 8+len(value))
+	binary.BigEndian.PutUint64(data, uint64(expires))
+	copy(data[8:], value)
+	if err := os.MkdirAll(string(d), 0755); err != nil {
+		return
+	}
+	tmp, err := ioutil.TempFile(string(d), ".tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	os.Rename(tmp.Name(), filepath.Join(string(d), key))
+}
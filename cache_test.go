@@ -0,0 +1,166 @@
+/*
+* File Name:	cache_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-06
+ */
+
+package youtu
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+//newCountingYoutu 返回记录每个接口请求次数的Youtu
+func newCountingYoutu() (*Youtu, map[string]int, func()) {
+	calls := make(map[string]int)
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		ifname := path.Base(r.URL.Path)
+		calls[ifname]++
+		switch ifname {
+		case "fuzzydetect":
+			w.Write([]byte(`{"errorcode":-1,"errormsg":"failed"}`))
+		case "addface":
+			w.Write([]byte(`{"added":1,"face_ids":["f2"]}`))
+		default:
+			w.Write([]byte(`{"session_id":"s1","face":[{"face_id":"f1"}],"candidates":[{"person_id":"p1"}]}`))
+		}
+	})
+	return y, calls, ts.Close
+}
+
+func TestCache(t *testing.T) {
+	y, calls, done := newCountingYoutu()
+	defer done()
+	y.SetCache(NewMemoryCache(10), CachePolicy{TTL: time.Hour, IdentityTTL: time.Hour})
+	data, err := ioutil.ReadFile(testDataDir + "imageA.jpg")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %s", err)
+	}
+
+	//同一图片内容的不同输入方式共享缓存
+	y.DetectFace(ImageData(data), false)
+	rsp, err := y.DetectFace(ImageFile(testDataDir+"imageA.jpg"), false)
+	if err != nil || len(rsp.Face) != 1 || calls["detectface"] != 1 {
+		t.Errorf("cached DetectFace: %#v, %v, calls: %v\n", rsp, err, calls)
+	}
+	y.DetectFace(ImageData(data), true)
+	y.DetectFace(ImageFile(testDataDir+"imageB.jpg"), false)
+	y.DetectFace(ImageReader(bytes.NewReader(data)), false)
+	if calls["detectface"] != 4 {
+		t.Errorf("detectface calls = %d, want 4\n", calls["detectface"])
+	}
+
+	//失败的返回不缓存
+	y.FuzzyDetect(ImageData(data))
+	if _, err = y.FuzzyDetect(ImageData(data)); err == nil || calls["fuzzydetect"] != 2 {
+		t.Errorf("FuzzyDetect: %v, calls: %v\n", err, calls)
+	}
+
+	//修改人脸库后识别结果失效
+	y.FaceIdentify("g1", ImageData(data))
+	y.FaceIdentify("g1", ImageData(data))
+	if calls["faceidentify"] != 1 {
+		t.Errorf("faceidentify calls = %d, want 1\n", calls["faceidentify"])
+	}
+	y.AddFace("p1", []Image{ImageData(data)}, "")
+	y.FaceIdentify("g1", ImageData(data))
+	y.InvalidateGallery()
+	y.FaceIdentify("g1", ImageData(data))
+	if calls["faceidentify"] != 3 {
+		t.Errorf("faceidentify calls = %d, want 3\n", calls["faceidentify"])
+	}
+	//检测接口不受人脸库修改影响
+	y.DetectFace(ImageData(data), false)
+	if calls["detectface"] != 4 {
+		t.Errorf("detectface calls = %d, want 4\n", calls["detectface"])
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	c := NewMemoryCache(2)
+	c.Set("a", []byte("1"), 0)
+	c.Set("b", []byte("2"), 0)
+	c.Get("a")
+	c.Set("c", []byte("3"), 0)
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("least recently used entry not evicted\n")
+	}
+	if v, ok := c.Get("a"); !ok || string(v) != "1" {
+		t.Errorf("Get(a) = %s, %v\n", v, ok)
+	}
+	c.Set("d", []byte("4"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := c.Get("d"); ok {
+		t.Errorf("expired entry returned\n")
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "youtu")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	c := DiskCache(dir)
+	c.Set("a", []byte(`{"face":[]}`), time.Hour)
+	if v, ok := c.Get("a"); !ok || string(v) != `{"face":[]}` {
+		t.Errorf("Get(a) = %s, %v\n", v, ok)
+	}
+	c.Set("b", []byte("2"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("expired entry returned\n")
+	}
+	if _, err := os.Stat(dir + "/b"); !os.IsNotExist(err) {
+		t.Errorf("expired file not removed: %v\n", err)
+	}
+
+	c.Set("c", []byte("3"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if err = c.Prune(); err != nil {
+		t.Errorf("Prune failed: %s\n", err)
+	}
+	if _, err := os.Stat(dir + "/c"); !os.IsNotExist(err) {
+		t.Errorf("expired file not pruned: %v\n", err)
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Errorf("unexpired entry pruned\n")
+	}
+}
+
+func TestDiskCacheGallery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "youtu")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	policy := CachePolicy{IdentityTTL: time.Hour}
+	y1, calls1, done1 := newCountingYoutu()
+	defer done1()
+	y1.SetCache(DiskCache(dir), policy)
+	image := ImageFile(testDataDir + "imageA.jpg")
+	y1.FaceIdentify("g1", image)
+
+	//重启后的客户端读取磁盘上保存的人脸库版本
+	y2, calls2, done2 := newCountingYoutu()
+	defer done2()
+	y2.SetCache(DiskCache(dir), policy)
+	y2.FaceIdentify("g1", image)
+	if calls2["faceidentify"] != 0 {
+		t.Errorf("faceidentify calls = %d, want 0\n", calls2["faceidentify"])
+	}
+	y2.DelPerson("p1")
+	y1.FaceIdentify("g1", image)
+	if calls1["faceidentify"] != 2 {
+		t.Errorf("faceidentify calls = %d, want 2\n", calls1["faceidentify"])
+	}
+}
@@ -0,0 +1,32 @@
+/*
+* File Name:	car.go
+* Description:  http://open.youtu.qq.com 车辆识别API
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-24
+ */
+
+package youtu
+
+//CarTag 车辆识别结果
+type CarTag struct {
+	Brand           string  `json:"brand"`            //品牌
+	BrandConfidence float32 `json:"brand_confidence"` //品牌的置信度
+	Type            string  `json:"type"`             //车型，如轿车、SUV
+	TypeConfidence  float32 `json:"type_confidence"`  //车型的置信度
+	Color           string  `json:"color"`            //颜色
+	ColorConfidence float32 `json:"color_confidence"` //颜色的置信度
+}
+
+//CarClassifyRsp 车辆识别返回
+type CarClassifyRsp struct {
+	Status
+	CarCoord ItemCoord `json:"carcoord"` //车辆在图片中的位置
+	CarTags  []CarTag  `json:"cartags"`  //识别结果，按置信度从高到低排列
+}
+
+//CarClassify 识别图片中车辆的位置、品牌、车型和颜色
+func (y *Youtu) CarClassify(image Image) (rsp CarClassifyRsp, err error) {
+	scale, err := y.imageRequest(serviceCar, "carclassify", image, &rsp)
+	rsp.CarCoord.scale(scale)
+	return
+}
@@ -0,0 +1,42 @@
+/*
+* File Name:	car_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-24
+ */
+
+package youtu
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCarClassify(t *testing.T) {
+	rsp, err := yt.CarClassify(ImageFile(testDataDir + "imageA.jpg"))
+	if err != nil {
+		t.Errorf("CarClassify failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestCarClassifyScale(t *testing.T) {
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/youtu/carapi/carclassify" {
+			t.Errorf("path = %s\n", r.URL.Path)
+		}
+		w.Write([]byte(`{"carcoord":{"x":5,"y":10,"width":50,"height":20},"cartags":[{"brand":"大众","brand_confidence":0.9,"color":"白色"}]}`))
+	})
+	defer ts.Close()
+	y.SetPreprocess(&Preprocess{MaxSide: 100})
+
+	rsp, err := y.CarClassify(ImageData(encodeTestImage(t, FormatPNG, 400, 200)))
+	if err != nil {
+		t.Errorf("CarClassify failed: %s\n", err)
+		return
+	}
+	if rsp.CarCoord != (ItemCoord{20, 40, 200, 80}) || len(rsp.CarTags) != 1 || rsp.CarTags[0].Brand != "大众" {
+		t.Errorf("rsp: %#v\n", rsp)
+	}
+}
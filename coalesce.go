@@ -0,0 +1,105 @@
+/*
+* File Name:	coalesce.go
+* Description:  合并同时进行的相同请求
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-07
+ */
+
+package youtu
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+//errFlightPanic 合并的请求在执行时panic，等待的调用者得到该错误
+var errFlightPanic = errors.New("coalesced request panicked")
+
+//flight 正在进行的请求
+type flight struct {
+	done chan struct{} //请求完成后关闭
+	dups int           //等待该请求结果的调用者数
+	body []byte
+	err  error
+	//canceled 执行请求的调用者的ctx被取消，等待的调用者需要重新执行
+	canceled bool
+}
+
+//flightGroup 合并同时进行的相同请求，WithContext返回的副本共享同一个flightGroup
+type flightGroup struct {
+	endpoints map[string]bool //需要合并的接口，"服务/接口名"
+	mu        sync.Mutex
+	flights   map[string]*flight
+}
+
+//SetCoalesce 设置需要合并请求的接口，格式与Call的endpoint相同，如"getinfo"、"imageapi/imagetag"。
+//同时进行的相同请求(接口、参数和图片内容都相同)只调用一次接口，所有调用者得到相同的返回。
+//第一个请求的ctx被取消时，等待它的调用者使用自己的ctx重新请求；等待的调用者自己的ctx被取消时立即返回。
+//io.Reader输入不会被合并。
+//不带参数时关闭合并(默认)
+func (y *Youtu) SetCoalesce(endpoints ...string) {
+	if len(endpoints) == 0 {
+		y.flights = nil
+		return
+	}
+	g := &flightGroup{
+		endpoints: make(map[string]bool),
+		flights:   make(map[string]*flight),
+	}
+	for _, endpoint := range endpoints {
+		service, ifname := splitEndpoint(endpoint)
+		g.endpoints[service+"/"+ifname] = true
+	}
+	y.flights = g
+}
+
+//coalesce 接口是否需要合并请求
+func (g *flightGroup) coalesce(service, ifname string) bool {
+	return g != nil && g.endpoints[service+"/"+ifname]
+}
+
+//do 执行key对应的请求，同一key正在进行时等待其结果或者ctx取消，shared表示结果来自其他调用者的请求。
+//等待的请求因为执行者的ctx取消而失败时重新执行。key为空时直接执行
+func (g *flightGroup) do(ctx context.Context, key string, fn func() ([]byte, error)) (body []byte, shared bool, err error) {
+	if key == "" {
+		body, err = fn()
+		return
+	}
+	g.mu.Lock()
+	for {
+		f, ok := g.flights[key]
+		if !ok {
+			break
+		}
+		f.dups++
+		g.mu.Unlock()
+		select {
+		case <-f.done:
+			if !f.canceled {
+				return f.body, true, f.err
+			}
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+		g.mu.Lock()
+	}
+	f := &flight{done: make(chan struct{})}
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	finished := false
+	defer func() {
+		if !finished {
+			f.err = errFlightPanic
+		}
+		g.mu.Lock()
+		delete(g.flights, key)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.body, f.err = fn()
+	f.canceled = f.err != nil && ctx.Err() != nil
+	finished = true
+	return f.body, false, f.err
+}
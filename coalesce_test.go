@@ -0,0 +1,190 @@
+/*
+* File Name:	coalesce_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-07
+ */
+
+package youtu
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalesce(t *testing.T) {
+	var getinfo, detectface int32
+	release := make(chan struct{})
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch path.Base(r.URL.Path) {
+		case "getinfo":
+			atomic.AddInt32(&getinfo, 1)
+			<-release
+			w.Write([]byte(`{"person_id":"` + req["person_id"].(string) + `","person_name":"Alice"}`))
+		case "detectface":
+			atomic.AddInt32(&detectface, 1)
+			<-release
+			w.Write([]byte(`{"face":[{"face_id":"f1"}]}`))
+		}
+	})
+	defer ts.Close()
+	y.SetCoalesce("getinfo")
+
+	var wg sync.WaitGroup
+	rsps := make([]GetInfoRsp, 10)
+	for i := range rsps {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			personID := "p1"
+			if i == 0 {
+				personID = "p2"
+			}
+			rsps[i], _ = y.GetInfo(personID)
+		}(i)
+	}
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			y.DetectFace(ImageFile(testDataDir+"imageA.jpg"), false)
+		}()
+	}
+	//等待2个getinfo和3个detectface请求到达服务器，并且p1的其余8个请求都在等待结果
+	waitFor(t, func() bool {
+		y.flights.mu.Lock()
+		defer y.flights.mu.Unlock()
+		dups := 0
+		for _, f := range y.flights.flights {
+			dups += f.dups
+		}
+		return atomic.LoadInt32(&getinfo) == 2 && atomic.LoadInt32(&detectface) == 3 && dups == 8
+	})
+	close(release)
+	wg.Wait()
+
+	//p1的9个请求合并为一次，p2单独请求；detectface没有设置合并
+	if getinfo != 2 || detectface != 3 {
+		t.Errorf("getinfo calls = %d, detectface calls = %d\n", getinfo, detectface)
+	}
+	for i, rsp := range rsps {
+		want := "p1"
+		if i == 0 {
+			want = "p2"
+		}
+		if rsp.PersonID != want || rsp.PersonName != "Alice" {
+			t.Errorf("rsp %d: %#v\n", i, rsp)
+		}
+	}
+}
+
+//waitFor 等待cond成立，超时后测试失败
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFlightGroupWaiter(t *testing.T) {
+	g := &flightGroup{flights: make(map[string]*flight)}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	leader := make(chan error)
+	go func() {
+		defer func() {
+			if recover() == nil {
+				leader <- errors.New("no panic")
+				return
+			}
+			leader <- nil
+		}()
+		g.do(context.Background(), "k", func() ([]byte, error) {
+			close(started)
+			<-release
+			panic("fetch failed")
+		})
+	}()
+	<-started
+
+	//等待者的ctx取消后立即返回，不等待第一个请求
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := g.do(ctx, "k", nil); err != context.Canceled {
+		t.Errorf("do err = %v, want context.Canceled\n", err)
+	}
+
+	//第一个请求panic时等待者得到错误，flight被删除
+	waiter := make(chan error)
+	go func() {
+		_, _, err := g.do(context.Background(), "k", nil)
+		waiter <- err
+	}()
+	waitFor(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return g.flights["k"].dups == 2
+	})
+	close(release)
+	if err := <-leader; err != nil {
+		t.Errorf("leader: %s\n", err)
+	}
+	if err := <-waiter; err != errFlightPanic {
+		t.Errorf("waiter err = %v, want errFlightPanic\n", err)
+	}
+	if len(g.flights) != 0 {
+		t.Errorf("flights not cleaned up: %v\n", g.flights)
+	}
+}
+
+func TestFlightGroupLeaderCanceled(t *testing.T) {
+	g := &flightGroup{flights: make(map[string]*flight)}
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	leader := make(chan error)
+	go func() {
+		_, _, err := g.do(ctx, "k", func() ([]byte, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		leader <- err
+	}()
+	<-started
+
+	//第一个请求的ctx取消后，等待者使用自己的ctx重新请求
+	waiter := make(chan []byte)
+	go func() {
+		body, shared, err := g.do(context.Background(), "k", func() ([]byte, error) {
+			return []byte("ok"), nil
+		})
+		if shared || err != nil {
+			t.Errorf("waiter shared = %v, err = %v\n", shared, err)
+		}
+		waiter <- body
+	}()
+	waitFor(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return g.flights["k"].dups == 1
+	})
+	cancel()
+	if err := <-leader; err != context.Canceled {
+		t.Errorf("leader err = %v, want context.Canceled\n", err)
+	}
+	if body := <-waiter; string(body) != "ok" {
+		t.Errorf("waiter body = %q, want ok\n", body)
+	}
+}
@@ -0,0 +1,295 @@
+/*
+* File Name:	encode.go
+* Description:  流式请求编码
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-05
+ */
+
+package youtu
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	payloadType   = reflect.TypeOf((*payload)(nil)).Elem()
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+//encodeJSON 将请求以JSON格式写入w，Image/Video字段以base64编码直接从数据来源流式写入，
+//避免在内存中保留图片数据的多份拷贝
+func encodeJSON(w io.Writer, v interface{}) error {
+	bw := bufio.NewWriter(w)
+	if err := encodeValue(bw, reflect.ValueOf(v)); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func encodeValue(w *bufio.Writer, v reflect.Value) error {
+	switch {
+	case !v.IsValid():
+		_, err := w.WriteString("null")
+		return err
+	case v.Type().Implements(marshalerType):
+	case (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil():
+		return encodeValue(w, v.Elem())
+	case v.Type().Implements(payloadType):
+		return encodePayload(w, v.Interface().(payload))
+	case v.Kind() == reflect.Struct:
+		return encodeStruct(w, v)
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String && !v.IsNil():
+		return encodeMap(w, v)
+	case isList(v) && !(v.Kind() == reflect.Slice && v.IsNil()):
+		w.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := encodeValue(w, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		w.WriteByte(']')
+		return nil
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+//isList 是否为需要逐个编码元素的数组或切片，[]byte与encoding/json相同以base64编码
+func isList(v reflect.Value) bool {
+	return (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8
+}
+
+//encodeMap 按key排序写入map，用于Call等使用map作为请求的情况
+func encodeMap(w *bufio.Writer, v reflect.Value) error {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	w.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		key, err := json.Marshal(k.String())
+		if err != nil {
+			return err
+		}
+		w.Write(key)
+		w.WriteByte(':')
+		if err := encodeValue(w, v.MapIndex(k)); err != nil {
+			return err
+		}
+	}
+	w.WriteByte('}')
+	return nil
+}
+
+//encodeStruct 按照json tag写入结构体字段，支持omitempty
+func encodeStruct(w *bufio.Writer, v reflect.Value) error {
+	w.WriteByte('{')
+	first := true
+	for _, f := range structFields(v.Type()) {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || f.omitempty && isEmptyValue(fv) {
+			continue
+		}
+		if !first {
+			w.WriteByte(',')
+		}
+		first = false
+		key, err := json.Marshal(f.name)
+		if err != nil {
+			return err
+		}
+		w.Write(key)
+		w.WriteByte(':')
+		if err := encodeValue(w, fv); err != nil {
+			return err
+		}
+	}
+	w.WriteByte('}')
+	return nil
+}
+
+//encodePayload 以JSON字符串的形式写入数据的base64编码
+func encodePayload(w *bufio.Writer, p payload) error {
+	r, err := p.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w.WriteByte('"')
+	enc := base64.NewEncoder(base64.StdEncoding, w)
+	n, err := io.Copy(enc, io.LimitReader(r, p.maxSize()+1))
+	if err != nil {
+		return err
+	}
+	if err := p.checkSize(n); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return w.WriteByte('"')
+}
+
+//structField 编码的结构体字段，index为从外层结构体开始的字段序号
+type structField struct {
+	name      string
+	index     []int
+	omitempty bool
+	tagged    bool //是否通过json tag指定了名称
+}
+
+//structFields 按encoding/json的规则返回t中编码的字段：没有指定名称的嵌入结构体展开其字段，
+//同名字段取嵌入层次最浅的，同一层次有多个时取唯一指定了json tag的，否则都忽略
+func structFields(t reflect.Type) []structField {
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+	var fields []structField
+	names := make(map[string]bool)         //较浅层次已经出现的名称
+	visited := make(map[reflect.Type]bool) //较浅层次已经展开的结构体
+	next := []embedded{{t, nil}}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		var order []string
+		byName := make(map[string][]structField)
+		for _, e := range current {
+			if visited[e.t] {
+				continue
+			}
+			for i := 0; i < e.t.NumField(); i++ {
+				f := e.t.Field(i)
+				ft := f.Type
+				if f.Anonymous && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if f.PkgPath != "" && !(f.Anonymous && ft.Kind() == reflect.Struct) {
+					continue
+				}
+				name, omitempty := parseTag(f)
+				if name == "-" {
+					continue
+				}
+				index := append(append([]int(nil), e.index...), i)
+				tagged := strings.Split(f.Tag.Get("json"), ",")[0] != ""
+				if f.Anonymous && ft.Kind() == reflect.Struct && !tagged {
+					next = append(next, embedded{ft, index})
+					continue
+				}
+				if f.PkgPath != "" || names[name] {
+					continue
+				}
+				if byName[name] == nil {
+					order = append(order, name)
+				}
+				byName[name] = append(byName[name], structField{name, index, omitempty, tagged})
+			}
+		}
+		for _, e := range current {
+			visited[e.t] = true
+		}
+		for _, name := range order {
+			names[name] = true
+			if f, ok := dominantField(byName[name]); ok {
+				fields = append(fields, f)
+			}
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return fields
+}
+
+//dominantField 同一层次的同名字段中唯一的一个或唯一指定了json tag的一个
+func dominantField(fields []structField) (structField, bool) {
+	if len(fields) == 1 {
+		return fields[0], true
+	}
+	var dominant []structField
+	for _, f := range fields {
+		if f.tagged {
+			dominant = append(dominant, f)
+		}
+	}
+	if len(dominant) == 1 {
+		return dominant[0], true
+	}
+	return structField{}, false
+}
+
+//fieldByIndex 返回嵌入字段中的值，经过nil指针时返回false
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func parseTag(f reflect.StructField) (name string, omitempty bool) {
+	tag := f.Tag.Get("json")
+	if tag == "" {
+		return f.Name, false
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = f.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	if v.Type().Implements(payloadType) {
+		return v.Interface().(payload).isZero()
+	}
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+/*
+* File Name:	encode_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-05
+ */
+
+package youtu
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeJSON(t *testing.T) {
+	imageA := []byte("image A data")
+	imageB := []byte("image B")
+	req := addFaceReq{
+		AppID:    "1000061",
+		PersonID: "ochapman",
+		Images:   []Image{ImageData(imageA), ImageReader(bytes.NewReader(imageB))},
+	}
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, req); err != nil {
+		t.Errorf("encodeJSON failed: %s\n", err)
+		return
+	}
+	want, err := json.Marshal(map[string]interface{}{
+		"app_id":    "1000061",
+		"person_id": "ochapman",
+		"images": []string{
+			base64.StdEncoding.EncodeToString(imageA),
+			base64.StdEncoding.EncodeToString(imageB),
+		},
+	})
+	if err != nil {
+		t.Errorf("json.Marshal failed: %s\n", err)
+		return
+	}
+	var got, exp map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Errorf("encodeJSON output %s invalid: %s\n", buf.String(), err)
+		return
+	}
+	json.Unmarshal(want, &exp)
+	gotJSON, _ := json.Marshal(got)
+	expJSON, _ := json.Marshal(exp)
+	if string(gotJSON) != string(expJSON) {
+		t.Errorf("encodeJSON = %s, want %s\n", gotJSON, expJSON)
+	}
+	if strings.Contains(buf.String(), "urls") || strings.Contains(buf.String(), "tag") {
+		t.Errorf("encodeJSON did not omit empty fields: %s\n", buf.String())
+	}
+}
+
+func TestEncodeJSONEmptyImage(t *testing.T) {
+	req := detectFaceReq{
+		AppID: "1000061",
+		Image: ImageReader(strings.NewReader("")),
+	}
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, req); err != ErrEmptyImage {
+		t.Errorf("encodeJSON err = %v, want %v\n", err, ErrEmptyImage)
+	}
+}
+
+func TestEncodeJSONMap(t *testing.T) {
+	image := []byte("image data")
+	req := map[string]interface{}{
+		"app_id": "1000061",
+		"image":  ImageData(image),
+		"images": []interface{}{ImageReader(bytes.NewReader(image)), "x"},
+		"data":   []byte("raw"),
+	}
+	if !hasReader(reflect.ValueOf(req)) {
+		t.Errorf("hasReader = false\n")
+	}
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, req); err != nil {
+		t.Fatalf("encodeJSON failed: %s\n", err)
+	}
+	b64 := base64.StdEncoding.EncodeToString(image)
+	want := `{"app_id":"1000061","data":"` + base64.StdEncoding.EncodeToString([]byte("raw")) +
+		`","image":"` + b64 + `","images":["` + b64 + `","x"]}`
+	if buf.String() != want {
+		t.Errorf("encodeJSON = %s, want %s\n", buf.String(), want)
+	}
+}
+
+type embedBase struct {
+	AppID string `json:"app_id"`
+	Name  string
+}
+
+type EmbedTagged struct {
+	Name string `json:"name"`
+}
+
+type EmbedInner struct {
+	Deep string `json:"deep"`
+	ID   string `json:"id"`
+}
+
+type EmbedPtr struct {
+	EmbedInner
+	Ptr string `json:"ptr"`
+}
+
+func TestEncodeJSONEmbedded(t *testing.T) {
+	image := []byte("image data")
+	req := struct {
+		embedBase
+		Image Image `json:"image"`
+		N     int   `json:"n"`
+	}{embedBase{AppID: "1000061"}, ImageReader(bytes.NewReader(image)), 3}
+	if !hasReader(reflect.ValueOf(req)) {
+		t.Errorf("hasReader = false\n")
+	}
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, req); err != nil {
+		t.Fatalf("encodeJSON failed: %s\n", err)
+	}
+	want := `{"app_id":"1000061","Name":"","image":"` + base64.StdEncoding.EncodeToString(image) + `","n":3}`
+	if buf.String() != want {
+		t.Errorf("encodeJSON = %s, want %s\n", buf.String(), want)
+	}
+
+	//不含Image字段时与encoding/json的输出相同
+	tests := []interface{}{
+		struct {
+			embedBase
+			EmbedTagged
+		}{embedBase{"1", "a"}, EmbedTagged{"b"}},
+		struct {
+			*EmbedPtr
+			EmbedInner `json:"inner"`
+			ID         string `json:"id"`
+		}{&EmbedPtr{EmbedInner{"d", "x"}, "p"}, EmbedInner{"e", "y"}, "z"},
+		struct {
+			*EmbedPtr
+			N int
+		}{nil, 1},
+		struct {
+			EmbedInner
+			EmbedPtr
+		}{EmbedInner{"d", "x"}, EmbedPtr{EmbedInner{"e", "y"}, "p"}},
+	}
+	for i, tt := range tests {
+		want, err := json.Marshal(tt)
+		if err != nil {
+			t.Fatalf("json.Marshal failed: %s\n", err)
+		}
+		buf.Reset()
+		if err := encodeJSON(&buf, tt); err != nil {
+			t.Errorf("%d: encodeJSON failed: %s\n", i, err)
+			continue
+		}
+		if buf.String() != string(want) {
+			t.Errorf("%d: encodeJSON = %s, want %s\n", i, buf.String(), want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+/*
+* File Name:	enroll.go
+* Description:  批量加入人脸
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-05
+ */
+
+package youtu
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	//AddFaceMaxImages 一次AddFace请求最多上传的图片数
+	AddFaceMaxImages = 5
+	//PersonMaxFaces 一个Person最多包含的Face数
+	PersonMaxFaces = 10000
+)
+
+var (
+	//ErrPersonFull 个体的人脸数已经达到PersonMaxFaces
+	ErrPersonFull = errors.New("person face limit reached")
+	//ErrFaceNotAdded 图片没有被加入，通常是图片中没有检测到人脸
+	ErrFaceNotAdded = errors.New("face not added")
+)
+
+//EnrollResult 批量加入人脸中一张图片的结果
+type EnrollResult struct {
+	Index  int    //图片在输入中的序号
+	FaceID string //加入后的face_id，失败时为空
+	Err    error  //失败的原因
+}
+
+//EnrollFaces 将images加入个体personID，按AddFaceMaxImages分批上传，返回与images一一对应的结果。
+//连续的url输入和图片数据输入分别成批上传。
+//一批中有图片失败时无法知道是哪一张，该批会逐张重新上传以找出失败的图片。
+//超过PersonMaxFaces的图片不会上传，结果为ErrPersonFull。
+//io.Reader输入在上传之前被读入内存，以便逐张重试。
+//只有获取个体已有人脸失败时返回err
+func (y *Youtu) EnrollFaces(personID string, images []Image, tag string) (results []EnrollResult, err error) {
+	rsp, err := y.GetFaceIDs(personID)
+	if err != nil {
+		return
+	}
+	results = make([]EnrollResult, len(images))
+	for i := range results {
+		results[i].Index = i
+	}
+	images = bufferImages(images, results)
+	room := PersonMaxFaces - len(rsp.FaceIDs)
+	if room < 0 {
+		room = 0
+	}
+	var todo []int
+	for i := range images {
+		switch {
+		case results[i].Err != nil:
+		case len(todo) >= room:
+			results[i].Err = ErrPersonFull
+		default:
+			todo = append(todo, i)
+		}
+	}
+	for _, chunk := range addFaceChunks(images, todo) {
+		if y.enrollChunk(personID, images, chunk, tag, results) {
+			continue
+		}
+		for _, i := range chunk {
+			y.enrollChunk(personID, images, []int{i}, tag, results)
+		}
+	}
+	return
+}
+
+//addFaceChunks 将images中序号为idx的图片按顺序分为最多AddFaceMaxImages张的批次。
+//AddFace返回的face_id先是图片数据后是url，每批只包含一种输入才能与输入对应
+func addFaceChunks(images []Image, idx []int) (chunks [][]int) {
+	for start, end := 0, 0; start < len(idx); start = end {
+		end = start + 1
+		for end < len(idx) && end-start < AddFaceMaxImages && images[idx[end]].IsURL() == images[idx[start]].IsURL() {
+			end++
+		}
+		chunks = append(chunks, idx[start:end])
+	}
+	return
+}
+
+//bufferImages 将只能读取一次的io.Reader输入读入内存，读取失败的结果记录在results中
+func bufferImages(images []Image, results []EnrollResult) []Image {
+	out := make([]Image, len(images))
+	for i, img := range images {
+		out[i] = img
+		if !img.isReader() {
+			continue
+		}
+		//多读取一个字节，超过ImageMaxSize的图片在上传前校验时失败
+		data, err := ioutil.ReadAll(io.LimitReader(img.reader, ImageMaxSize+1))
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		out[i] = ImageData(data)
+	}
+	return out
+}
+
+//enrollChunk 上传images中序号为idx的一批图片，所有图片都加入成功时记录face_id并返回true。
+//部分图片加入成功时删除已加入的人脸以便逐张重试；只有一张图片时记录失败原因
+func (y *Youtu) enrollChunk(personID string, images []Image, idx []int, tag string, results []EnrollResult) bool {
+	chunk := make([]Image, len(idx))
+	for j, i := range idx {
+		chunk[j] = images[i]
+	}
+	rsp, err := y.AddFace(personID, chunk, tag)
+	if err == nil && len(rsp.FaceIDs) == len(idx) {
+		for j, faceID := range rsp.FaceIDs {
+			results[idx[j]].FaceID = faceID
+		}
+		return true
+	}
+	if len(idx) > 1 && len(rsp.FaceIDs) > 0 {
+		if _, derr := y.DelFace(personID, rsp.FaceIDs); derr != nil {
+			//无法撤销时不再重试，避免重复加入
+			for _, i := range idx {
+				results[i].Err = derr
+			}
+			return true
+		}
+	}
+	if len(idx) == 1 {
+		if err == nil {
+			err = ErrFaceNotAdded
+		}
+		results[idx[0]].Err = err
+	}
+	return false
+}
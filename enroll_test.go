@@ -0,0 +1,124 @@
+/*
+* File Name:	enroll_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-05
+ */
+
+package youtu
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestEnrollFaces(t *testing.T) {
+	y, g, done := newFakeYoutu()
+	defer done()
+	jpg := ImageFile(testDataDir + "imageA.jpg")
+	y.NewPerson("alice", "Alice", []string{"dev"}, jpg, "")
+	//fakeGallery中PNG图片没有人脸
+	noFace := ImageData(encodeTestImage(t, FormatPNG, 64, 64))
+	images := []Image{jpg, jpg, noFace, jpg, jpg, jpg, jpg, noFace}
+
+	results, err := y.EnrollFaces("alice", images, "")
+	if err != nil {
+		t.Fatalf("EnrollFaces failed: %s\n", err)
+	}
+	if len(results) != len(images) {
+		t.Fatalf("results = %d\n", len(results))
+	}
+	added := 0
+	for i, r := range results {
+		if r.Index != i {
+			t.Errorf("result %d has index %d\n", i, r.Index)
+		}
+		if i == 2 || i == 7 {
+			if r.Err == nil || r.FaceID != "" {
+				t.Errorf("result %d: %#v\n", i, r)
+			}
+			continue
+		}
+		if r.Err != nil || g.faces[r.FaceID] != "alice" {
+			t.Errorf("result %d: %#v\n", i, r)
+		}
+		added++
+	}
+	//两批都有失败的图片，部分加入的人脸被删除后逐张重试
+	if n := len(g.persons["alice"].faces); n != added+1 {
+		t.Errorf("alice has %d faces, want %d\n", n, added+1)
+	}
+	if g.calls["addface"] != 2+8 || g.calls["delface"] != 2 {
+		t.Errorf("calls: %v\n", g.calls)
+	}
+}
+
+func TestEnrollFacesLimit(t *testing.T) {
+	y, g, done := newFakeYoutu()
+	defer done()
+	jpg := ImageFile(testDataDir + "imageA.jpg")
+	y.NewPerson("alice", "Alice", []string{"dev"}, jpg, "")
+	for i := 1; i < PersonMaxFaces-1; i++ {
+		g.newFace("alice")
+	}
+	results, err := y.EnrollFaces("alice", []Image{jpg, jpg, jpg}, "")
+	if err != nil {
+		t.Fatalf("EnrollFaces failed: %s\n", err)
+	}
+	if results[0].Err != nil || results[1].Err != ErrPersonFull || results[2].Err != ErrPersonFull {
+		t.Errorf("results: %#v\n", results)
+	}
+	if n := len(g.persons["alice"].faces); n != PersonMaxFaces {
+		t.Errorf("alice has %d faces\n", n)
+	}
+}
+
+func TestEnrollFacesReader(t *testing.T) {
+	y, g, done := newFakeYoutu()
+	defer done()
+	jpg, err := ioutil.ReadFile(testDataDir + "imageA.jpg")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %s", err)
+	}
+	y.NewPerson("alice", "Alice", []string{"dev"}, ImageData(jpg), "")
+	noFace := encodeTestImage(t, FormatPNG, 64, 64)
+	//第一批失败后逐张重试，io.Reader输入需要能再次读取
+	images := []Image{ImageReader(bytes.NewReader(jpg)), ImageReader(bytes.NewReader(noFace))}
+
+	results, err := y.EnrollFaces("alice", images, "")
+	if err != nil {
+		t.Fatalf("EnrollFaces failed: %s\n", err)
+	}
+	if results[0].Err != nil || g.faces[results[0].FaceID] != "alice" {
+		t.Errorf("result 0: %#v\n", results[0])
+	}
+	if e, ok := results[1].Err.(*APIError); !ok || e.Code != -1101 {
+		t.Errorf("result 1: %#v\n", results[1])
+	}
+}
+
+func TestEnrollFacesMixedURL(t *testing.T) {
+	y, g, done := newFakeYoutu()
+	defer done()
+	jpg := ImageFile(testDataDir + "imageA.jpg")
+	y.NewPerson("alice", "Alice", []string{"dev"}, jpg, "")
+	url := "http://example.com/a.jpg"
+	images := []Image{ImageURL(url), jpg, jpg, ImageURL(url)}
+
+	results, err := y.EnrollFaces("alice", images, "")
+	if err != nil {
+		t.Fatalf("EnrollFaces failed: %s\n", err)
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Errorf("result %d: %#v\n", i, r)
+		}
+		if _, isURL := g.urls[r.FaceID]; isURL != images[i].IsURL() {
+			t.Errorf("result %d: face %s does not match input\n", i, r.FaceID)
+		}
+	}
+	if g.calls["addface"] != 3 {
+		t.Errorf("addface called %d times, want 3\n", g.calls["addface"])
+	}
+}
@@ -0,0 +1,118 @@
+/*
+* File Name:	exif.go
+* Description:  JPEG EXIF方向解析和像素旋转
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-12
+ */
+
+package youtu
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+const exifOrientationTag = 0x0112
+
+//exifOrientation 解析JPEG中EXIF的方向(1~8)，hasExif表示图片是否带有EXIF信息
+func exifOrientation(data []byte) (orientation int, hasExif bool) {
+	orientation = 1
+	if !bytes.HasPrefix(data, []byte{0xff, 0xd8}) {
+		return
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return
+		}
+		marker := data[i+1]
+		if marker == 0xda || marker == 0xd9 {
+			//图像数据开始，之后不会再有EXIF
+			return
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if size < 2 || i+2+size > len(data) {
+			return
+		}
+		seg := data[i+4 : i+2+size]
+		if marker == 0xe1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			hasExif = true
+			if o := tiffOrientation(seg[6:]); o >= 1 && o <= 8 {
+				orientation = o
+			}
+			return
+		}
+		i += 2 + size
+	}
+	return
+}
+
+//tiffOrientation 从EXIF的TIFF结构的IFD0中读取方向
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	//偏移量在32位平台上转换为int可能溢出为负数，先以int64比较
+	offset := int64(order.Uint32(tiff[4:8]))
+	if offset+2 > int64(len(tiff)) {
+		return 0
+	}
+	ifd := int(offset)
+	n := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < n; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) == exifOrientationTag {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+	return 0
+}
+
+//orient 按照EXIF方向旋转/翻转像素，得到图片显示时的方向
+func orient(src image.Image, orientation int) *image.RGBA {
+	s := toRGBA(src)
+	w, h := s.Bounds().Dx(), s.Bounds().Dy()
+	if orientation <= 1 || orientation > 8 {
+		return s
+	}
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: //水平翻转
+				sx, sy = w-1-x, y
+			case 3: //旋转180度
+				sx, sy = w-1-x, h-1-y
+			case 4: //垂直翻转
+				sx, sy = x, h-1-y
+			case 5: //沿左上-右下对角线翻转
+				sx, sy = y, x
+			case 6: //顺时针旋转90度
+				sx, sy = y, h-1-x
+			case 7: //沿右上-左下对角线翻转
+				sx, sy = w-1-y, h-1-x
+			case 8: //逆时针旋转90度
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], s.Pix[s.PixOffset(sx, sy):s.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
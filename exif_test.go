@@ -0,0 +1,108 @@
+/*
+* File Name:	exif_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-12
+ */
+
+package youtu
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io/ioutil"
+	"testing"
+)
+
+//jpegWithOrientation 生成左半边黑色、右半边白色并带有EXIF方向的JPEG
+func jpegWithOrientation(t *testing.T, w, h, orientation int) []byte {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := w / 2; x < w; x++ {
+			img.SetGray(x, y, color.Gray{255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("jpeg.Encode failed: %s", err)
+	}
+	var exif bytes.Buffer
+	exif.WriteString("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08")
+	binary.Write(&exif, binary.BigEndian, uint16(1))
+	binary.Write(&exif, binary.BigEndian, []uint16{exifOrientationTag, 3, 0, 1, uint16(orientation), 0})
+	binary.Write(&exif, binary.BigEndian, uint32(0))
+	data := buf.Bytes()
+	var out bytes.Buffer
+	out.Write(data[:2])
+	out.Write([]byte{0xff, 0xe1})
+	binary.Write(&out, binary.BigEndian, uint16(exif.Len()+2))
+	out.Write(exif.Bytes())
+	out.Write(data[2:])
+	return out.Bytes()
+}
+
+func TestExifOrientation(t *testing.T) {
+	data := jpegWithOrientation(t, 40, 20, 6)
+	if o, hasExif := exifOrientation(data); o != 6 || !hasExif {
+		t.Errorf("exifOrientation() = %d, %v\n", o, hasExif)
+	}
+	plain, err := ioutil.ReadFile(testDataDir + "imageB.jpg")
+	if err != nil {
+		t.Errorf("ReadFile failed: %s\n", err)
+		return
+	}
+	if o, _ := exifOrientation(plain); o != 1 {
+		t.Errorf("exifOrientation() = %d, want 1\n", o)
+	}
+	//超出范围的IFD偏移量被忽略
+	for _, tiff := range []string{"MM\x00\x2a\xff\xff\xff\xf0", "II\x2a\x00\xfe\xff\xff\xff"} {
+		if o := tiffOrientation([]byte(tiff)); o != 0 {
+			t.Errorf("tiffOrientation(%q) = %d, want 0\n", tiff, o)
+		}
+	}
+}
+
+func TestPreprocessAutoOrient(t *testing.T) {
+	p := &Preprocess{AutoOrient: true}
+	img, scale, err := p.apply(ImageData(jpegWithOrientation(t, 40, 20, 6)))
+	if err != nil || scale != 1 {
+		t.Errorf("apply() scale %v, err %v\n", scale, err)
+		return
+	}
+	if _, hasExif := exifOrientation(img.data); hasExif {
+		t.Errorf("EXIF not stripped\n")
+	}
+	dst, err := jpeg.Decode(bytes.NewReader(img.data))
+	if err != nil {
+		t.Errorf("jpeg.Decode failed: %s\n", err)
+		return
+	}
+	if b := dst.Bounds(); b.Dx() != 20 || b.Dy() != 40 {
+		t.Errorf("oriented bounds = %v\n", b)
+	}
+	//顺时针旋转90度后，原图左半边的黑色在上半部分
+	top, _, _, _ := dst.At(10, 5).RGBA()
+	bottom, _, _, _ := dst.At(10, 35).RGBA()
+	if top > 0x4000 || bottom < 0xc000 {
+		t.Errorf("pixels not rotated: top %x, bottom %x\n", top, bottom)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	src.Set(0, 0, color.White)
+	//原图左上角的像素在各个方向下显示的位置
+	want := map[int]image.Point{
+		2: {2, 0}, 3: {2, 1}, 4: {0, 1},
+		5: {0, 0}, 6: {1, 0}, 7: {1, 2}, 8: {0, 2},
+	}
+	for o, p := range want {
+		dst := orient(src, o)
+		if r, _, _, _ := dst.At(p.X, p.Y).RGBA(); r != 0xffff {
+			t.Errorf("orientation %d: pixel not at %v\n", o, p)
+		}
+	}
+}
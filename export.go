@@ -0,0 +1,304 @@
+/*
+* File Name:	export.go
+* Description:  导出人脸库快照
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-02
+ */
+
+package youtu
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	//SnapshotVersion 快照格式的版本
+	SnapshotVersion = 1
+	//DefaultExportConcurrency Export默认的并发请求数
+	DefaultExportConcurrency = 4
+)
+
+var (
+	//ErrSnapshotVersion 快照版本高于当前支持的SnapshotVersion
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+)
+
+//Snapshot 人脸库快照
+type Snapshot struct {
+	Version int              `json:"version"`           //快照格式的版本
+	AppID   string           `json:"app_id"`            //App的 API ID
+	Created time.Time        `json:"created"`           //导出时间
+	Groups  []string         `json:"groups"`            //所有的组
+	Persons []SnapshotPerson `json:"persons,omitempty"` //所有的个体，按person_id排序
+}
+
+//SnapshotPerson 快照中的个体
+type SnapshotPerson struct {
+	PersonID   string   `json:"person_id"`   //个体id
+	PersonName string   `json:"person_name"` //名字
+	Tag        string   `json:"tag"`         //备注信息
+	GroupIDs   []string `json:"group_ids"`   //所属的组
+	Faces      []Face   `json:"faces"`       //人脸及其属性
+}
+
+//Export 导出人脸库中所有的组、个体和人脸，concurrency为同时进行的请求数，
+//小于等于0时使用DefaultExportConcurrency。任何请求失败时返回错误
+func (y *Youtu) Export(concurrency int) (*Snapshot, error) {
+	return y.snapshot(concurrency, true)
+}
+
+//snapshot 导出人脸库，faceInfo为false时不获取人脸属性，Faces中只有face_id
+func (y *Youtu) snapshot(concurrency int, faceInfo bool) (*Snapshot, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultExportConcurrency
+	}
+	snap := &Snapshot{
+		Version: SnapshotVersion,
+		AppID:   y.appID(),
+		Created: time.Now().UTC().Round(0),
+	}
+	groupRsp, err := y.GetGroupIDs()
+	if err != nil {
+		return nil, err
+	}
+	snap.Groups = append([]string{}, groupRsp.GroupIDs...)
+	sort.Strings(snap.Groups)
+	seen := make(map[string]bool)
+	var personIDs []string
+	for _, groupID := range snap.Groups {
+		rsp, err := y.GetPersonIDs(groupID)
+		if err != nil {
+			return nil, err
+		}
+		for _, personID := range rsp.PersonIDs {
+			if !seen[personID] {
+				seen[personID] = true
+				personIDs = append(personIDs, personID)
+			}
+		}
+	}
+	sort.Strings(personIDs)
+
+	snap.Persons = make([]SnapshotPerson, len(personIDs))
+	jobs := make(chan int)
+	errc := make(chan error, 1)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				p, err := y.exportPerson(personIDs[i], faceInfo)
+				if err != nil {
+					select {
+					case errc <- err:
+						close(stop)
+					default:
+					}
+					continue
+				}
+				snap.Persons[i] = p
+			}
+		}()
+	}
+dispatch:
+	for i := range personIDs {
+		select {
+		case jobs <- i:
+		case <-stop:
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	select {
+	case err = <-errc:
+		return nil, err
+	default:
+	}
+	return snap, nil
+}
+
+//exportPerson 获取个体信息及其所有人脸(的属性)
+func (y *Youtu) exportPerson(personID string, faceInfo bool) (p SnapshotPerson, err error) {
+	info, err := y.GetInfo(personID)
+	if err != nil {
+		return
+	}
+	faceRsp, err := y.GetFaceIDs(personID)
+	if err != nil {
+		return
+	}
+	p = SnapshotPerson{
+		PersonID:   personID,
+		PersonName: info.PersonName,
+		Tag:        info.Tag,
+		GroupIDs:   append([]string{}, info.GroupIDs...),
+		Faces:      make([]Face, 0, len(faceRsp.FaceIDs)),
+	}
+	sort.Strings(p.GroupIDs)
+	for _, faceID := range faceRsp.FaceIDs {
+		if !faceInfo {
+			p.Faces = append(p.Faces, Face{FaceID: faceID})
+			continue
+		}
+		rsp, err := y.GetFaceInfo(faceID)
+		if err != nil {
+			return p, err
+		}
+		face := rsp.FaceInfo
+		face.FaceID = faceID
+		p.Faces = append(p.Faces, face)
+	}
+	sort.Slice(p.Faces, func(i, j int) bool {
+		return p.Faces[i].FaceID < p.Faces[j].FaceID
+	})
+	return
+}
+
+//WriteJSON 以单个JSON对象写入快照
+func (s *Snapshot) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(s)
+}
+
+//WriteJSONL 以JSON Lines写入快照，第一行为不含个体的快照头，之后每行一个个体
+func (s *Snapshot) WriteJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	head := *s
+	head.Persons = nil
+	if err := enc.Encode(head); err != nil {
+		return err
+	}
+	for _, p := range s.Persons {
+		if err := enc.Encode(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//ReadSnapshot 读取WriteJSON或WriteJSONL写入的快照
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	dec := json.NewDecoder(r)
+	s := &Snapshot{}
+	if err := dec.Decode(s); err != nil {
+		return nil, err
+	}
+	if s.Version > SnapshotVersion {
+		return nil, ErrSnapshotVersion
+	}
+	for {
+		var p SnapshotPerson
+		err := dec.Decode(&p)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.Persons = append(s.Persons, p)
+	}
+	return s, nil
+}
+
+//PersonChange 两个快照中同一个体的差异
+type PersonChange struct {
+	PersonID     string   //个体id
+	Fields       []string //发生变化的字段：person_name, tag, group_ids
+	AddedFaces   []string //新增的face_id
+	RemovedFaces []string //删除的face_id
+}
+
+//SnapshotDiff 两个快照的差异
+type SnapshotDiff struct {
+	AddedGroups    []string       //新增的组
+	RemovedGroups  []string       //删除的组
+	AddedPersons   []string       //新增的个体
+	RemovedPersons []string       //删除的个体
+	ChangedPersons []PersonChange //发生变化的个体
+}
+
+//Empty 两个快照是否一致
+func (d SnapshotDiff) Empty() bool {
+	return len(d.AddedGroups) == 0 && len(d.RemovedGroups) == 0 && len(d.AddedPersons) == 0 &&
+		len(d.RemovedPersons) == 0 && len(d.ChangedPersons) == 0
+}
+
+//DiffSnapshots 比较快照a到b的变化
+func DiffSnapshots(a, b *Snapshot) SnapshotDiff {
+	var d SnapshotDiff
+	d.AddedGroups, d.RemovedGroups = diffStrings(a.Groups, b.Groups)
+	persons := make(map[string]*SnapshotPerson, len(a.Persons))
+	for i := range a.Persons {
+		persons[a.Persons[i].PersonID] = &a.Persons[i]
+	}
+	for i := range b.Persons {
+		pb := &b.Persons[i]
+		pa, ok := persons[pb.PersonID]
+		if !ok {
+			d.AddedPersons = append(d.AddedPersons, pb.PersonID)
+			continue
+		}
+		delete(persons, pb.PersonID)
+		c := PersonChange{PersonID: pb.PersonID}
+		if pa.PersonName != pb.PersonName {
+			c.Fields = append(c.Fields, "person_name")
+		}
+		if pa.Tag != pb.Tag {
+			c.Fields = append(c.Fields, "tag")
+		}
+		if !sameStrings(pa.GroupIDs, pb.GroupIDs) {
+			c.Fields = append(c.Fields, "group_ids")
+		}
+		c.AddedFaces, c.RemovedFaces = diffStrings(faceIDs(pa.Faces), faceIDs(pb.Faces))
+		if len(c.Fields) > 0 || len(c.AddedFaces) > 0 || len(c.RemovedFaces) > 0 {
+			d.ChangedPersons = append(d.ChangedPersons, c)
+		}
+	}
+	for personID := range persons {
+		d.RemovedPersons = append(d.RemovedPersons, personID)
+	}
+	sort.Strings(d.AddedPersons)
+	sort.Strings(d.RemovedPersons)
+	sort.Slice(d.ChangedPersons, func(i, j int) bool {
+		return d.ChangedPersons[i].PersonID < d.ChangedPersons[j].PersonID
+	})
+	return d
+}
+
+func faceIDs(faces []Face) []string {
+	ids := make([]string, len(faces))
+	for i, f := range faces {
+		ids[i] = f.FaceID
+	}
+	return ids
+}
+
+//diffStrings 返回b中新增和a中被删除的字符串
+func diffStrings(a, b []string) (added, removed []string) {
+	inA := make(map[string]bool, len(a))
+	for _, s := range a {
+		inA[s] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, s := range b {
+		inB[s] = true
+		if !inA[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range a {
+		if !inB[s] {
+			removed = append(removed, s)
+		}
+	}
+	return
+}
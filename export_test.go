@@ -0,0 +1,84 @@
+/*
+* File Name:	export_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-02
+ */
+
+package youtu
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	y, g, done := newFakeYoutu()
+	defer done()
+	img := ImageFile(testDataDir + "imageA.jpg")
+	y.NewPerson("alice", "Alice", []string{"dev"}, img, `{"employee_id":"E001"}`)
+	y.AddFace("alice", []Image{img}, "")
+	y.NewPerson("bob", "Bob", []string{"dev", "ops"}, img, "")
+
+	snap, err := y.Export(2)
+	if err != nil {
+		t.Fatalf("Export failed: %s\n", err)
+	}
+	if len(snap.Groups) != 2 || len(snap.Persons) != 2 || snap.Version != SnapshotVersion {
+		t.Fatalf("snapshot: %#v\n", snap)
+	}
+	alice := snap.Persons[0]
+	if alice.PersonID != "alice" || alice.Tag != `{"employee_id":"E001"}` || len(alice.Faces) != 2 || alice.Faces[0].Age != 30 {
+		t.Errorf("alice: %#v\n", alice)
+	}
+	if g.calls["getfaceinfo"] != 3 {
+		t.Errorf("getfaceinfo calls = %d\n", g.calls["getfaceinfo"])
+	}
+
+	for _, write := range []func(*Snapshot, *bytes.Buffer) error{
+		func(s *Snapshot, b *bytes.Buffer) error { return s.WriteJSON(b) },
+		func(s *Snapshot, b *bytes.Buffer) error { return s.WriteJSONL(b) },
+	} {
+		var buf bytes.Buffer
+		if err = write(snap, &buf); err != nil {
+			t.Errorf("write failed: %s\n", err)
+		}
+		got, err := ReadSnapshot(&buf)
+		if err != nil {
+			t.Errorf("ReadSnapshot failed: %s\n", err)
+			continue
+		}
+		if !reflect.DeepEqual(got, snap) {
+			t.Errorf("ReadSnapshot = %#v\nwant %#v\n", got, snap)
+		}
+	}
+
+	y.SetInfo("bob", "Robert", "")
+	y.DelPerson("alice")
+	y.NewPerson("carol", "Carol", []string{"qa"}, img, "")
+	next, err := y.Export(0)
+	if err != nil {
+		t.Fatalf("Export failed: %s\n", err)
+	}
+	d := DiffSnapshots(snap, next)
+	want := SnapshotDiff{
+		AddedGroups:    []string{"qa"},
+		AddedPersons:   []string{"carol"},
+		RemovedPersons: []string{"alice"},
+		ChangedPersons: []PersonChange{{PersonID: "bob", Fields: []string{"person_name"}}},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("DiffSnapshots = %#v\n", d)
+	}
+	if !DiffSnapshots(next, next).Empty() {
+		t.Errorf("DiffSnapshots of same snapshot not empty\n")
+	}
+}
+
+func TestReadSnapshotVersion(t *testing.T) {
+	if _, err := ReadSnapshot(strings.NewReader(`{"version":99}`)); err != ErrSnapshotVersion {
+		t.Errorf("ReadSnapshot err = %v\n", err)
+	}
+}
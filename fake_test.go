@@ -0,0 +1,220 @@
+/*
+* File Name:	fake_test.go
+* Description:  测试用的优图人脸服务
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-27
+ */
+
+package youtu
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type fakePerson struct {
+	name   string
+	tag    string
+	groups []string
+	faces  []string
+}
+
+//fakeGallery 在内存中模拟个体、组和人脸的管理接口
+type fakeGallery struct {
+	mu      sync.Mutex
+	persons map[string]*fakePerson
+	faces   map[string]string //face_id -> person_id
+	urls    map[string]string //由url加入的face_id -> url
+	nextID  int
+	calls   map[string]int
+}
+
+func newFakeGallery() *fakeGallery {
+	return &fakeGallery{
+		persons: make(map[string]*fakePerson),
+		faces:   make(map[string]string),
+		urls:    make(map[string]string),
+		calls:   make(map[string]int),
+	}
+}
+
+func (g *fakeGallery) newFace(personID string) string {
+	g.nextID++
+	id := fmt.Sprintf("face%d", g.nextID)
+	g.faces[id] = personID
+	p := g.persons[personID]
+	p.faces = append(p.faces, id)
+	return id
+}
+
+func (g *fakeGallery) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PersonID   string   `json:"person_id"`
+		PersonName string   `json:"person_name"`
+		GroupID    string   `json:"group_id"`
+		GroupIDs   []string `json:"group_ids"`
+		FaceID     string   `json:"face_id"`
+		FaceIDs    []string `json:"face_ids"`
+		Images     []string `json:"images"`
+		URLs       []string `json:"urls"`
+		Tag        *string  `json:"tag"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ifname := path.Base(r.URL.Path)
+	g.calls[ifname]++
+	rsp := map[string]interface{}{"errorcode": 0, "errormsg": "OK"}
+	p := g.persons[req.PersonID]
+	notFound := func() {
+		rsp["errorcode"] = -1001
+		rsp["errormsg"] = "ERROR_PERSON_NOT_EXISTED"
+	}
+	switch ifname {
+	case "newperson":
+		if p != nil {
+			rsp["errorcode"] = -1302
+			rsp["errormsg"] = "ERROR_PERSON_EXISTED"
+			break
+		}
+		p = &fakePerson{name: req.PersonName, groups: req.GroupIDs}
+		if req.Tag != nil {
+			p.tag = *req.Tag
+		}
+		g.persons[req.PersonID] = p
+		rsp["person_id"] = req.PersonID
+		rsp["face_id"] = g.newFace(req.PersonID)
+		rsp["suc_group"] = len(req.GroupIDs)
+		rsp["suc_face"] = 1
+	case "delperson":
+		if p == nil {
+			notFound()
+			break
+		}
+		for _, f := range p.faces {
+			delete(g.faces, f)
+		}
+		delete(g.persons, req.PersonID)
+		rsp["deleted"] = 1
+	case "addface":
+		if p == nil {
+			notFound()
+			break
+		}
+		if len(req.Images)+len(req.URLs) > AddFaceMaxImages {
+			rsp["errorcode"] = -1
+			rsp["errormsg"] = "too many images"
+			break
+		}
+		//PNG图片(base64以"iVBO"开头)视为没有人脸
+		var ids []string
+		for _, img := range req.Images {
+			if strings.HasPrefix(img, "iVBO") {
+				continue
+			}
+			ids = append(ids, g.newFace(req.PersonID))
+		}
+		//与优图相同，url加入的人脸排在图片数据之后
+		for _, url := range req.URLs {
+			id := g.newFace(req.PersonID)
+			g.urls[id] = url
+			ids = append(ids, id)
+		}
+		if len(ids) == 0 {
+			rsp["errorcode"] = -1101
+			rsp["errormsg"] = "ERROR_NO_FACE"
+		}
+		rsp["added"] = len(ids)
+		rsp["face_ids"] = ids
+	case "delface":
+		if p == nil {
+			notFound()
+			break
+		}
+		deleted := 0
+		for _, id := range req.FaceIDs {
+			if g.faces[id] != req.PersonID {
+				continue
+			}
+			delete(g.faces, id)
+			p.faces = replaceString(p.faces, id, "")
+			deleted++
+		}
+		rsp["deleted"] = deleted
+	case "setinfo":
+		if p == nil {
+			notFound()
+			break
+		}
+		if req.PersonName != "" {
+			p.name = req.PersonName
+		}
+		if req.Tag != nil {
+			p.tag = *req.Tag
+		}
+		rsp["person_id"] = req.PersonID
+	case "getinfo":
+		if p == nil {
+			notFound()
+			break
+		}
+		rsp["person_id"] = req.PersonID
+		rsp["person_name"] = p.name
+		rsp["tag"] = p.tag
+		rsp["group_ids"] = p.groups
+		rsp["face_ids"] = p.faces
+	case "getgroupids":
+		seen := make(map[string]bool)
+		ids := []string{}
+		for _, p := range g.persons {
+			for _, id := range p.groups {
+				if !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+		sort.Strings(ids)
+		rsp["group_ids"] = ids
+	case "getpersonids":
+		ids := []string{}
+		for id, p := range g.persons {
+			for _, gid := range p.groups {
+				if gid == req.GroupID {
+					ids = append(ids, id)
+				}
+			}
+		}
+		sort.Strings(ids)
+		rsp["person_ids"] = ids
+	case "getfaceids":
+		if p == nil {
+			notFound()
+			break
+		}
+		rsp["face_ids"] = p.faces
+	case "getfaceinfo":
+		if _, ok := g.faces[req.FaceID]; !ok {
+			rsp["errorcode"] = -1003
+			rsp["errormsg"] = "ERROR_FACE_NOT_EXISTED"
+			break
+		}
+		rsp["face_info"] = map[string]interface{}{"face_id": req.FaceID, "age": 30}
+	default:
+		rsp["errorcode"] = -1
+		rsp["errormsg"] = "unknown interface " + ifname
+	}
+	json.NewEncoder(w).Encode(rsp)
+}
+
+//newFakeYoutu 返回连接到fakeGallery的Youtu
+func newFakeYoutu() (*Youtu, *fakeGallery, func()) {
+	g := newFakeGallery()
+	y, ts := newTestYoutu(g.ServeHTTP)
+	return y, g, ts.Close
+}
@@ -0,0 +1,304 @@
+/*
+* File Name:	group.go
+* Description:  组管理
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-27
+ */
+
+package youtu
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	//ErrNoFaceImage 个体没有可用于重新创建的人脸图片
+	ErrNoFaceImage = errors.New("no face image to recreate person")
+)
+
+//FaceImages 按face_id提供人脸图片。
+//优图不能修改个体所属的组，也不能下载已加入的人脸图片，
+//修改组时需要删除个体并使用原来的人脸图片重新创建
+type FaceImages interface {
+	FaceImage(faceID string) (Image, error)
+}
+
+//FaceImageStore 重新创建个体后face_id会改变，实现FaceImageStore可以同步更新face_id
+type FaceImageStore interface {
+	FaceImages
+	Rename(oldFaceID, newFaceID string) error
+}
+
+//FaceImageDir 以"face_id.jpg"为文件名保存人脸图片的目录
+type FaceImageDir string
+
+func (d FaceImageDir) path(faceID string) string {
+	return filepath.Join(string(d), faceID+".jpg")
+}
+
+//FaceImage 返回faceID对应的图片文件
+func (d FaceImageDir) FaceImage(faceID string) (Image, error) {
+	p := d.path(faceID)
+	if _, err := os.Stat(p); err != nil {
+		return Image{}, err
+	}
+	return ImageFile(p), nil
+}
+
+//Rename 将图片文件重命名为新的face_id
+func (d FaceImageDir) Rename(oldFaceID, newFaceID string) error {
+	return os.Rename(d.path(oldFaceID), d.path(newFaceID))
+}
+
+//GroupError 组操作中部分个体失败时返回的错误
+type GroupError struct {
+	Op     string           //操作名称
+	Done   []string         //成功处理的个体
+	Failed map[string]error //处理失败的个体(或无法列出个体的组)及原因
+}
+
+func (e *GroupError) Error() string {
+	ids := make([]string, 0, len(e.Failed))
+	for id := range e.Failed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = fmt.Sprintf("%s: %s", id, e.Failed[id])
+	}
+	return fmt.Sprintf("%s: %d done, %d failed: %s", e.Op, len(e.Done), len(e.Failed), strings.Join(msgs, "; "))
+}
+
+//err 没有失败的个体时返回nil
+func (e *GroupError) err() error {
+	if len(e.Failed) > 0 {
+		return e
+	}
+	return nil
+}
+
+//RecreateError 修改组时个体已经被删除，但没有完全重新创建
+type RecreateError struct {
+	PersonID string //个体id
+	Err      error  //重新创建时的错误
+}
+
+func (e *RecreateError) Error() string {
+	return fmt.Sprintf("person %s deleted but not fully recreated: %s", e.PersonID, e.Err)
+}
+
+//SetPersonGroups 将个体所属的组修改为groupIDs，groupIDs为空时删除个体。
+//个体会使用faces中的原图重新创建，name和tag保持不变，face_id会改变
+func (y *Youtu) SetPersonGroups(personID string, groupIDs []string, faces FaceImages) error {
+	return y.updatePersonGroups(personID, faces, func([]string) []string {
+		return groupIDs
+	})
+}
+
+//updatePersonGroups 使用update修改个体所属的组
+func (y *Youtu) updatePersonGroups(personID string, faces FaceImages, update func(groups []string) []string) error {
+	info, err := y.GetInfo(personID)
+	if err != nil {
+		return err
+	}
+	groupIDs := uniqueStrings(update(append([]string(nil), info.GroupIDs...)))
+	if sameStrings(info.GroupIDs, groupIDs) {
+		return nil
+	}
+	if len(groupIDs) == 0 {
+		_, err = y.DelPerson(personID)
+		return err
+	}
+	if len(info.FaceIDs) == 0 {
+		return ErrNoFaceImage
+	}
+	//在删除个体之前取得并校验所有图片，避免删除后无法恢复
+	images := make([]Image, len(info.FaceIDs))
+	for i, faceID := range info.FaceIDs {
+		if images[i], err = faces.FaceImage(faceID); err != nil {
+			return fmt.Errorf("face %s: %s", faceID, err)
+		}
+	}
+	newReq, err := y.newPersonReq(personID, info.PersonName, groupIDs, images[0], info.Tag)
+	if err == nil {
+		err = validateRequest(newReq)
+	}
+	if err != nil {
+		return fmt.Errorf("face %s: %s", info.FaceIDs[0], err)
+	}
+	//其余人脸分批加入，每批不超过AddFaceMaxImages张并且只包含一种输入，使face_id能与输入对应
+	rest := make([]int, len(images)-1)
+	for i := range rest {
+		rest[i] = i + 1
+	}
+	chunks := addFaceChunks(images, rest)
+	addReqs := make([]addFaceReq, len(chunks))
+	for j, chunk := range chunks {
+		chunkImages := make([]Image, len(chunk))
+		for k, i := range chunk {
+			chunkImages[k] = images[i]
+		}
+		if addReqs[j], err = y.addFaceReq(personID, chunkImages, ""); err == nil {
+			err = validateRequest(addReqs[j])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if _, err = y.DelPerson(personID); err != nil {
+		return err
+	}
+	var newRsp NewPersonRsp
+	if err = y.interfaceRequest("newperson", newReq, &newRsp); err != nil {
+		return &RecreateError{PersonID: personID, Err: err}
+	}
+	newFaceIDs := make([]string, len(images))
+	newFaceIDs[0] = newRsp.FaceID
+	//某一批失败时继续加入其他批次，尽量保留人脸
+	var addErr error
+	for j, chunk := range chunks {
+		var addRsp AddFaceRsp
+		err = y.interfaceRequest("addface", addReqs[j], &addRsp)
+		if err == nil && len(addRsp.FaceIDs) != len(chunk) {
+			//无法确定这一批中新旧face_id的对应关系
+			err = fmt.Errorf("%d of %d faces added", len(addRsp.FaceIDs), len(chunk))
+		}
+		if err != nil {
+			if addErr == nil {
+				addErr = err
+			}
+			continue
+		}
+		for k, i := range chunk {
+			newFaceIDs[i] = addRsp.FaceIDs[k]
+		}
+	}
+	//只重命名能确定对应关系的人脸图片
+	if store, ok := faces.(FaceImageStore); ok {
+		for i, faceID := range info.FaceIDs {
+			if newFaceIDs[i] == "" {
+				continue
+			}
+			if err = store.Rename(faceID, newFaceIDs[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if addErr != nil {
+		return &RecreateError{PersonID: personID, Err: addErr}
+	}
+	return nil
+}
+
+//AddPersonToGroups 将已有的个体加入groupIDs中的组
+func (y *Youtu) AddPersonToGroups(personID string, groupIDs []string, faces FaceImages) error {
+	return y.updatePersonGroups(personID, faces, func(groups []string) []string {
+		return append(groups, groupIDs...)
+	})
+}
+
+//RemovePersonFromGroup 将个体从组中移除，个体不再属于任何组时被删除
+func (y *Youtu) RemovePersonFromGroup(personID string, groupID string, faces FaceImages) error {
+	return y.updatePersonGroups(personID, faces, func(groups []string) []string {
+		return replaceString(groups, groupID, "")
+	})
+}
+
+//RenameGroup 将组oldID中的所有个体移动到组newID
+func (y *Youtu) RenameGroup(oldID, newID string, faces FaceImages) error {
+	return y.updateGroup("RenameGroup", oldID, faces, func(groups []string) []string {
+		return replaceString(groups, oldID, newID)
+	})
+}
+
+//MergeGroups 将srcIDs中所有组的个体合并到组dstID
+func (y *Youtu) MergeGroups(srcIDs []string, dstID string, faces FaceImages) error {
+	gerr := &GroupError{Op: "MergeGroups", Failed: make(map[string]error)}
+	for _, srcID := range srcIDs {
+		srcID := srcID
+		y.updateGroupPersons(gerr, srcID, faces, func(groups []string) []string {
+			return replaceString(groups, srcID, dstID)
+		})
+	}
+	return gerr.err()
+}
+
+//DeleteGroup 将组中的所有个体移出该组，只属于该组的个体被删除。
+//组中没有个体后不再出现在GetGroupIDs中
+func (y *Youtu) DeleteGroup(groupID string, faces FaceImages) error {
+	return y.updateGroup("DeleteGroup", groupID, faces, func(groups []string) []string {
+		return replaceString(groups, groupID, "")
+	})
+}
+
+//updateGroup 对组groupID中的每个个体修改所属的组，部分个体失败时返回*GroupError
+func (y *Youtu) updateGroup(op string, groupID string, faces FaceImages, update func(groups []string) []string) error {
+	gerr := &GroupError{Op: op, Failed: make(map[string]error)}
+	y.updateGroupPersons(gerr, groupID, faces, update)
+	return gerr.err()
+}
+
+//updateGroupPersons 修改组groupID中每个个体所属的组，结果记录在gerr中
+func (y *Youtu) updateGroupPersons(gerr *GroupError, groupID string, faces FaceImages, update func(groups []string) []string) {
+	rsp, err := y.GetPersonIDs(groupID)
+	if err != nil {
+		gerr.Failed[groupID] = err
+		return
+	}
+	for _, personID := range rsp.PersonIDs {
+		if err := y.updatePersonGroups(personID, faces, update); err != nil {
+			gerr.Failed[personID] = err
+			continue
+		}
+		gerr.Done = append(gerr.Done, personID)
+	}
+}
+
+//replaceString 将ss中的from替换为to，to为空时删除from
+func replaceString(ss []string, from, to string) []string {
+	out := ss[:0]
+	for _, s := range ss {
+		if s != from {
+			out = append(out, s)
+		} else if to != "" {
+			out = append(out, to)
+		}
+	}
+	return out
+}
+
+//uniqueStrings 去除重复和空的字符串
+func uniqueStrings(ss []string) []string {
+	seen := make(map[string]bool, len(ss))
+	out := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out
+}
+
+//sameStrings 判断两个字符串集合是否相同
+func sameStrings(a, b []string) bool {
+	a, b = uniqueStrings(a), uniqueStrings(b)
+	if len(a) != len(b) {
+		return false
+	}
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
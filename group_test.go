@@ -0,0 +1,218 @@
+/*
+* File Name:	group_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-27
+ */
+
+package youtu
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//newFaceImageDir 创建保存face_id对应图片的临时目录
+func newFaceImageDir(t *testing.T, faceIDs ...string) FaceImageDir {
+	dir, err := ioutil.TempDir("", "youtu")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+	data, err := ioutil.ReadFile(testDataDir + "imageA.jpg")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %s", err)
+	}
+	for _, id := range faceIDs {
+		if err := ioutil.WriteFile(filepath.Join(dir, id+".jpg"), data, 0644); err != nil {
+			t.Fatalf("WriteFile failed: %s", err)
+		}
+	}
+	return FaceImageDir(dir)
+}
+
+func TestGroupOperations(t *testing.T) {
+	y, g, done := newFakeYoutu()
+	defer done()
+	img := ImageFile(testDataDir + "imageA.jpg")
+	y.NewPerson("alice", "Alice", []string{"dev"}, img, "")
+	y.AddFace("alice", []Image{img}, "")
+	y.NewPerson("bob", "Bob", []string{"dev", "ops"}, img, "")
+	faces := newFaceImageDir(t, "face1", "face2", "face3")
+	defer os.RemoveAll(string(faces))
+
+	if err := y.AddPersonToGroups("alice", []string{"ops"}, faces); err != nil {
+		t.Errorf("AddPersonToGroups failed: %s\n", err)
+	}
+	alice := g.persons["alice"]
+	if !sameStrings(alice.groups, []string{"dev", "ops"}) || len(alice.faces) != 2 || alice.name != "Alice" {
+		t.Errorf("alice: %#v\n", alice)
+	}
+	//重新创建后图片以新的face_id保存
+	for _, id := range alice.faces {
+		if _, err := faces.FaceImage(id); err != nil {
+			t.Errorf("FaceImage(%s) failed: %s\n", id, err)
+		}
+	}
+
+	if err := y.RenameGroup("dev", "rd", faces); err != nil {
+		t.Errorf("RenameGroup failed: %s\n", err)
+	}
+	if !sameStrings(g.persons["bob"].groups, []string{"rd", "ops"}) {
+		t.Errorf("bob groups: %v\n", g.persons["bob"].groups)
+	}
+
+	if err := y.DeleteGroup("rd", faces); err != nil {
+		t.Errorf("DeleteGroup failed: %s\n", err)
+	}
+	rsp, _ := y.GetGroupIDs()
+	if !sameStrings(rsp.GroupIDs, []string{"ops"}) {
+		t.Errorf("groups after DeleteGroup: %v\n", rsp.GroupIDs)
+	}
+
+	if err := y.RemovePersonFromGroup("bob", "ops", faces); err != nil {
+		t.Errorf("RemovePersonFromGroup failed: %s\n", err)
+	}
+	if _, ok := g.persons["bob"]; ok {
+		t.Errorf("bob not deleted after leaving last group\n")
+	}
+}
+
+func TestMergeGroupsPartialFailure(t *testing.T) {
+	y, g, done := newFakeYoutu()
+	defer done()
+	img := ImageFile(testDataDir + "imageA.jpg")
+	y.NewPerson("alice", "Alice", []string{"a"}, img, "")
+	y.NewPerson("bob", "Bob", []string{"b"}, img, "")
+	//只有alice的图片，bob无法重新创建
+	faces := newFaceImageDir(t, "face1")
+	defer os.RemoveAll(string(faces))
+
+	err := y.MergeGroups([]string{"a", "b"}, "all", faces)
+	gerr, ok := err.(*GroupError)
+	if !ok {
+		t.Errorf("MergeGroups err = %v, want *GroupError\n", err)
+		return
+	}
+	if len(gerr.Done) != 1 || gerr.Done[0] != "alice" || gerr.Failed["bob"] == nil {
+		t.Errorf("GroupError: %s\n", gerr)
+	}
+	if !sameStrings(g.persons["bob"].groups, []string{"b"}) {
+		t.Errorf("bob modified: %v\n", g.persons["bob"].groups)
+	}
+}
+
+func TestUpdatePersonGroupsInvalidImage(t *testing.T) {
+	y, g, done := newFakeYoutu()
+	defer done()
+	img := ImageFile(testDataDir + "imageA.jpg")
+	y.NewPerson("alice", "Alice", []string{"dev"}, img, "")
+	y.AddFace("alice", []Image{img}, "")
+	faces := newFaceImageDir(t, "face1", "face2")
+	defer os.RemoveAll(string(faces))
+	if err := ioutil.WriteFile(faces.path("face2"), []byte("not an image"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %s", err)
+	}
+
+	//图片在删除个体之前校验，失败时个体保持不变
+	if err := y.AddPersonToGroups("alice", []string{"ops"}, faces); err == nil {
+		t.Errorf("AddPersonToGroups succeeded with corrupt image\n")
+	}
+	if g.calls["delperson"] != 0 {
+		t.Errorf("delperson called %d times\n", g.calls["delperson"])
+	}
+	if _, err := y.GetInfo("alice"); err != nil {
+		t.Errorf("GetInfo failed: %s\n", err)
+	}
+}
+
+func TestUpdatePersonGroupsPartialRecreate(t *testing.T) {
+	y, g, done := newFakeYoutu()
+	defer done()
+	img := ImageFile(testDataDir + "imageA.jpg")
+	y.NewPerson("alice", "Alice", []string{"dev"}, img, "")
+	y.AddFace("alice", []Image{img, img}, "")
+	faces := newFaceImageDir(t, "face1", "face2", "face3")
+	defer os.RemoveAll(string(faces))
+	//fakeGallery中PNG图片没有人脸，重新创建后只有2个人脸
+	if err := ioutil.WriteFile(faces.path("face3"), encodeTestImage(t, FormatPNG, 10, 10), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %s", err)
+	}
+
+	err := y.AddPersonToGroups("alice", []string{"ops"}, faces)
+	rerr, ok := err.(*RecreateError)
+	if !ok || rerr.PersonID != "alice" {
+		t.Fatalf("AddPersonToGroups err = %v, want *RecreateError\n", err)
+	}
+	if len(g.persons["alice"].faces) != 2 {
+		t.Errorf("alice faces: %v\n", g.persons["alice"].faces)
+	}
+	//face1重新创建后被重命名，face2和face3所在的批次对应关系不确定，不被重命名
+	if _, err := faces.FaceImage("face1"); !os.IsNotExist(err) {
+		t.Errorf("FaceImage(face1) err = %v, want not exist\n", err)
+	}
+	for _, id := range []string{"face2", "face3"} {
+		if _, err := faces.FaceImage(id); err != nil {
+			t.Errorf("FaceImage(%s) failed: %s\n", id, err)
+		}
+	}
+}
+
+//mixedFaces 部分face_id以url提供的FaceImageStore，记录重命名
+type mixedFaces struct {
+	FaceImageDir
+	urls    map[string]string //face_id -> url
+	renamed map[string]string
+}
+
+func (m *mixedFaces) FaceImage(faceID string) (Image, error) {
+	if url, ok := m.urls[faceID]; ok {
+		return ImageURL(url), nil
+	}
+	return m.FaceImageDir.FaceImage(faceID)
+}
+
+func (m *mixedFaces) Rename(oldFaceID, newFaceID string) error {
+	m.renamed[oldFaceID] = newFaceID
+	if url, ok := m.urls[oldFaceID]; ok {
+		m.urls[newFaceID] = url
+		return nil
+	}
+	return m.FaceImageDir.Rename(oldFaceID, newFaceID)
+}
+
+func TestUpdatePersonGroupsChunks(t *testing.T) {
+	y, g, done := newFakeYoutu()
+	defer done()
+	img := ImageFile(testDataDir + "imageA.jpg")
+	y.NewPerson("alice", "Alice", []string{"dev"}, img, "")
+	for i := 0; i < 3; i++ {
+		y.AddFace("alice", []Image{img, img, img}, "")
+	}
+	//10个人脸，其中face3和face6以url提供
+	dir := newFaceImageDir(t, "face1", "face2", "face4", "face5", "face7", "face8", "face9", "face10")
+	defer os.RemoveAll(string(dir))
+	faces := &mixedFaces{
+		FaceImageDir: dir,
+		urls:         map[string]string{"face3": "http://example.com/3.jpg", "face6": "http://example.com/6.jpg"},
+		renamed:      make(map[string]string),
+	}
+
+	if err := y.AddPersonToGroups("alice", []string{"ops"}, faces); err != nil {
+		t.Fatalf("AddPersonToGroups failed: %s\n", err)
+	}
+	if n := len(g.persons["alice"].faces); n != 10 {
+		t.Errorf("alice has %d faces, want 10\n", n)
+	}
+	if len(faces.renamed) != 10 {
+		t.Errorf("renamed: %v\n", faces.renamed)
+	}
+	//url输入的人脸重命名为url加入的face_id
+	for oldID, newID := range faces.renamed {
+		_, wasURL := faces.urls[oldID]
+		if _, isURL := g.urls[newID]; isURL != wasURL {
+			t.Errorf("%s renamed to %s\n", oldID, newID)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+/*
+* File Name:	image.go
+* Description:  图片输入
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-02
+ */
+
+package youtu
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+var (
+	//ErrEmptyImage 图片为空错误
+	ErrEmptyImage = errors.New("empty image")
+)
+
+//payload 在请求中以base64编码流式上传的数据，如图片和视频
+type payload interface {
+	isZero() bool
+	open() (io.ReadCloser, error)
+	maxSize() int64
+	checkSize(n int64) error //检查上传的字节数n是否为0或超过maxSize
+	isReader() bool          //是否为只能读取一次的io.Reader
+}
+
+//source 上传数据的来源
+type source struct {
+	data   []byte      //二进制数据
+	reader *headReader //数据来源
+	path   string      //本地文件路径
+	url    string      //远程URL
+}
+
+//isZero 是否为空数据
+func (s source) isZero() bool {
+	return len(s.data) == 0 && s.reader == nil && s.path == "" && s.url == ""
+}
+
+func (s source) isReader() bool {
+	return s.reader != nil
+}
+
+//open 打开数据来源
+func (s source) open() (io.ReadCloser, error) {
+	switch {
+	case len(s.data) > 0:
+		return ioutil.NopCloser(bytes.NewReader(s.data)), nil
+	case s.reader != nil:
+		return ioutil.NopCloser(s.reader), nil
+	case s.path != "":
+		return os.Open(s.path)
+	}
+	return nil, ErrEmptyImage
+}
+
+//Image 图片输入，可以是二进制数据、io.Reader、本地文件路径或者远程URL
+type Image struct {
+	source
+}
+
+//ImageData 使用二进制数据作为图片输入
+func ImageData(data []byte) Image {
+	return Image{source{data: data}}
+}
+
+//ImageReader 使用io.Reader作为图片输入，r只会被读取一次
+func ImageReader(r io.Reader) Image {
+	return Image{source{reader: &headReader{r: r}}}
+}
+
+//ImageFile 使用本地文件作为图片输入
+func ImageFile(path string) Image {
+	return Image{source{path: path}}
+}
+
+//ImageURL 使用远程图片URL作为图片输入，由优图服务器下载图片，不需要上传图片数据
+func ImageURL(url string) Image {
+	return Image{source{url: url}}
+}
+
+//IsURL 是否为远程图片URL
+func (img Image) IsURL() bool {
+	return img.url != ""
+}
+
+func (img Image) maxSize() int64 {
+	return ImageMaxSize
+}
+
+func (img Image) checkSize(n int64) error {
+	switch {
+	case n == 0:
+		return ErrEmptyImage
+	case n > ImageMaxSize:
+		return ErrImageTooLarge
+	}
+	return nil
+}
+
+//fields 返回请求中image和url字段的值，两者只有一个非空。
+//图片数据在发送请求时才被读取并编码
+func (img Image) fields() (data Image, url string, err error) {
+	if img.isZero() {
+		err = ErrEmptyImage
+		return
+	}
+	if img.IsURL() {
+		url = img.url
+		return
+	}
+	data = img
+	return
+}
+
+//imageReq 只需要提供图片的请求
+type imageReq struct {
+	AppID string `json:"app_id"`          //App的 API ID
+	Image Image  `json:"image,omitempty"` //使用base64编码的二进制图片数据
+	URL   string `json:"url,omitempty"`   //图片的url, image和url只需提供一个
+}
+
+//imageRequest 请求只需要提供图片的接口，返回原图相对于上传图片的缩放比例
+func (y *Youtu) imageRequest(service, ifname string, image Image, rsp interface{}) (scale float64, err error) {
+	image, scale, err = y.preprocessImage(image)
+	if err != nil {
+		return
+	}
+	data, url, err := image.fields()
+	if err != nil {
+		return
+	}
+	req := imageReq{
+		AppID: y.appID(),
+		Image: data,
+		URL:   url,
+	}
+	err = y.serviceRequest(service, ifname, req, rsp)
+	return
+}
@@ -0,0 +1,49 @@
+/*
+* File Name:	image_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-02
+ */
+
+package youtu
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestImageOpen(t *testing.T) {
+	imgData, err := ioutil.ReadFile(testDataDir + "imageA.jpg")
+	if err != nil {
+		t.Errorf("ReadFile failed: %s\n", err)
+		return
+	}
+	images := map[string]Image{
+		"data":   ImageData(imgData),
+		"reader": ImageReader(bytes.NewReader(imgData)),
+		"file":   ImageFile(testDataDir + "imageA.jpg"),
+	}
+	for name, img := range images {
+		r, err := img.open()
+		if err != nil {
+			t.Errorf("%s: open() failed: %s\n", name, err)
+			continue
+		}
+		data, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil || !bytes.Equal(data, imgData) {
+			t.Errorf("%s: read %d bytes, err %v\n", name, len(data), err)
+		}
+	}
+
+	data, url, err := ImageURL("http://example.com/a.jpg").fields()
+	if err != nil || !data.isZero() || url != "http://example.com/a.jpg" {
+		t.Errorf("url: fields() = %#v, %q, %v\n", data, url, err)
+	}
+
+	_, _, err = ImageData(nil).fields()
+	if err != ErrEmptyImage {
+		t.Errorf("empty: fields() err = %v, want %v\n", err, ErrEmptyImage)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+* File Name:	imageapi.go
+* Description:  http://open.youtu.qq.com 图像识别API
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-18
+ */
+
+package youtu
+
+//imagePorn接口返回的主要标签
+const (
+	//PornTagNormal 正常
+	PornTagNormal = "normal"
+	//PornTagHot 性感
+	PornTagHot = "hot"
+	//PornTagPorn 色情
+	PornTagPorn = "porn"
+)
+
+//ImageTag 图像标签
+type ImageTag struct {
+	TagName       string `json:"tag_name"`       //标签名称
+	TagConfidence int    `json:"tag_confidence"` //标签置信度[0,100]
+}
+
+//ImageTagRsp 图像标签识别返回
+type ImageTagRsp struct {
+	Status
+	Tags []ImageTag `json:"tags"` //图像的标签列表
+}
+
+//ImageTag 识别图像中的物体和场景，返回标签及置信度
+func (y *Youtu) ImageTag(image Image) (rsp ImageTagRsp, err error) {
+	_, err = y.imageRequest(serviceImage, "imagetag", image, &rsp)
+	return
+}
+
+//ImagePornRsp 色情图像检测返回
+type ImagePornRsp struct {
+	Status
+	Tags []ImageTag `json:"tags"` //检测的标签列表，包括normal/hot/porn等
+}
+
+//Confidence 返回标签name的置信度，没有该标签时返回-1
+func (rsp ImagePornRsp) Confidence(name string) int {
+	for _, tag := range rsp.Tags {
+		if tag.TagName == name {
+			return tag.TagConfidence
+		}
+	}
+	return -1
+}
+
+//Normal 正常图像的置信度
+func (rsp ImagePornRsp) Normal() int {
+	return rsp.Confidence(PornTagNormal)
+}
+
+//Hot 性感图像的置信度
+func (rsp ImagePornRsp) Hot() int {
+	return rsp.Confidence(PornTagHot)
+}
+
+//Porn 色情图像的置信度
+func (rsp ImagePornRsp) Porn() int {
+	return rsp.Confidence(PornTagPorn)
+}
+
+//ImagePorn 检测图像是否为色情或性感图像
+func (y *Youtu) ImagePorn(image Image) (rsp ImagePornRsp, err error) {
+	_, err = y.imageRequest(serviceImage, "imageporn", image, &rsp)
+	return
+}
+
+//FuzzyDetectRsp 模糊检测返回
+type FuzzyDetectRsp struct {
+	Status
+	Fuzzy           bool    `json:"fuzzy"`            //图像是否模糊
+	FuzzyConfidence float32 `json:"fuzzy_confidence"` //模糊的置信度
+}
+
+//FuzzyDetect 判断图像是否模糊
+func (y *Youtu) FuzzyDetect(image Image) (rsp FuzzyDetectRsp, err error) {
+	_, err = y.imageRequest(serviceImage, "fuzzydetect", image, &rsp)
+	return
+}
+
+//FoodDetectRsp 美食检测返回
+type FoodDetectRsp struct {
+	Status
+	Food           bool    `json:"food"`            //是否为美食图像
+	FoodConfidence float32 `json:"food_confidence"` //美食的置信度
+}
+
+//FoodDetect 判断图像是否为美食图像
+func (y *Youtu) FoodDetect(image Image) (rsp FoodDetectRsp, err error) {
+	_, err = y.imageRequest(serviceImage, "fooddetect", image, &rsp)
+	return
+}
@@ -0,0 +1,68 @@
+/*
+* File Name:	imageapi_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-18
+ */
+
+package youtu
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestImageTag(t *testing.T) {
+	rsp, err := yt.ImageTag(ImageFile(testDataDir + "imageA.jpg"))
+	if err != nil {
+		t.Errorf("ImageTag failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestImagePorn(t *testing.T) {
+	rsp, err := yt.ImagePorn(ImageFile(testDataDir + "imageA.jpg"))
+	if err != nil {
+		t.Errorf("ImagePorn failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestFuzzyDetect(t *testing.T) {
+	rsp, err := yt.FuzzyDetect(ImageFile(testDataDir + "imageA.jpg"))
+	if err != nil {
+		t.Errorf("FuzzyDetect failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestFoodDetect(t *testing.T) {
+	rsp, err := yt.FoodDetect(ImageFile(testDataDir + "imageA.jpg"))
+	if err != nil {
+		t.Errorf("FoodDetect failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestImagePornConfidence(t *testing.T) {
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/youtu/imageapi/imageporn" {
+			t.Errorf("path = %s\n", r.URL.Path)
+		}
+		w.Write([]byte(`{"tags":[{"tag_name":"normal","tag_confidence":80},{"tag_name":"hot","tag_confidence":15},{"tag_name":"porn","tag_confidence":5}]}`))
+	})
+	defer ts.Close()
+
+	rsp, err := y.ImagePorn(ImageFile(testDataDir + "imageA.jpg"))
+	if err != nil {
+		t.Errorf("ImagePorn failed: %s\n", err)
+		return
+	}
+	if rsp.Normal() != 80 || rsp.Hot() != 15 || rsp.Porn() != 5 || rsp.Confidence("breast") != -1 {
+		t.Errorf("rsp: %#v\n", rsp)
+	}
+}
@@ -0,0 +1,59 @@
+/*
+* File Name:	limit.go
+* Description:  请求频率限制
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-04
+ */
+
+package youtu
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//rateLimiter 按固定间隔发放请求配额
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time //下一个可以发送请求的时间
+}
+
+//wait 等待直到可以发送请求，ctx取消时返回ctx.Err()。l为nil时不限制
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	t := l.next
+	if t.Before(now) {
+		t = now
+	}
+	l.next = t.Add(l.interval)
+	l.mu.Unlock()
+
+	d := t.Sub(now)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//SetRateLimit 限制每秒最多发送qps个请求，所有接口以及WithContext返回的副本共享该限制。
+//qps小于等于0时不限制(默认)
+func (y *Youtu) SetRateLimit(qps float64) {
+	if qps <= 0 {
+		y.limiter = nil
+		return
+	}
+	y.limiter = &rateLimiter{interval: time.Duration(float64(time.Second) / qps)}
+}
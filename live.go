@@ -0,0 +1,260 @@
+/*
+* File Name:	live.go
+* Description:  http://open.youtu.qq.com 活体检测API
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-21
+ */
+
+package youtu
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+const (
+	//VideoMaxSize 上传视频数据的最大字节数
+	VideoMaxSize = 20 << 20
+)
+
+var (
+	//ErrEmptyVideo 视频为空错误
+	ErrEmptyVideo = errors.New("empty video")
+	//ErrVideoTooLarge 视频数据超过VideoMaxSize
+	ErrVideoTooLarge = errors.New("video too large")
+	//ErrCardURL 唇语活体检测的比对图片只能上传图片数据，不支持url
+	ErrCardURL = errors.New("card image must be data, url not supported")
+)
+
+//Video 视频输入，可以是二进制数据、io.Reader或者本地文件路径
+type Video struct {
+	source
+}
+
+//VideoData 使用二进制数据作为视频输入
+func VideoData(data []byte) Video {
+	return Video{source{data: data}}
+}
+
+//VideoReader 使用io.Reader作为视频输入，r只会被读取一次
+func VideoReader(r io.Reader) Video {
+	return Video{source{reader: &headReader{r: r}}}
+}
+
+//VideoFile 使用本地文件作为视频输入
+func VideoFile(path string) Video {
+	return Video{source{path: path}}
+}
+
+func (v Video) maxSize() int64 {
+	return VideoMaxSize
+}
+
+func (v Video) checkSize(n int64) error {
+	switch {
+	case n == 0:
+		return ErrEmptyVideo
+	case n > VideoMaxSize:
+		return ErrVideoTooLarge
+	}
+	return nil
+}
+
+//validate 在上传之前校验二进制数据和本地文件的大小，io.Reader来源的视频大小在发送时校验
+func (v Video) validate() error {
+	switch {
+	case len(v.data) > 0:
+		return v.checkSize(int64(len(v.data)))
+	case v.path != "":
+		fi, err := os.Stat(v.path)
+		if err != nil {
+			return err
+		}
+		return v.checkSize(fi.Size())
+	}
+	return nil
+}
+
+type liveGetFourReq struct {
+	AppID string `json:"app_id"` //App的 API ID
+}
+
+//LiveGetFourRsp 获取唇语验证码返回
+type LiveGetFourRsp struct {
+	Status
+	ValidateData string `json:"validate_data"` //唇语验证码，用户需要在录制的视频中读出
+}
+
+//LiveGetFour 获取唇语活体检测的验证码
+func (y *Youtu) LiveGetFour() (rsp LiveGetFourRsp, err error) {
+	req := liveGetFourReq{
+		AppID: y.appID(),
+	}
+	err = y.serviceRequest(serviceLive, "livegetfour", req, &rsp)
+	return
+}
+
+type liveDetectFourReq struct {
+	AppID        string `json:"app_id"`         //App的 API ID
+	ValidateData string `json:"validate_data"`  //LiveGetFour获取的唇语验证码
+	Video        Video  `json:"video"`          //使用base64编码的视频数据
+	Card         Image  `json:"card,omitempty"` //使用base64编码的比对图片数据
+	CompareFlag  bool   `json:"compare_flag"`   //是否将视频中的人脸与card比对
+}
+
+//LiveDetectFourRsp 唇语活体检测返回
+type LiveDetectFourRsp struct {
+	Status
+	LiveStatus    int     `json:"live_status"`    //活体检测结果，0表示通过
+	LiveMsg       string  `json:"live_msg"`       //活体检测结果描述
+	CompareStatus int     `json:"compare_status"` //人脸比对结果，0表示通过
+	CompareMsg    string  `json:"compare_msg"`    //人脸比对结果描述
+	Sim           float32 `json:"sim"`            //视频中的人脸与比对图片的相似度
+	Photo         string  `json:"photo"`          //视频中人脸最清晰的一帧，base64编码
+}
+
+//IsLive 是否通过活体检测
+func (rsp LiveDetectFourRsp) IsLive() bool {
+	return rsp.ErrorCode == 0 && rsp.LiveStatus == 0
+}
+
+//IsSamePerson 视频中的人脸是否与比对图片为同一人
+func (rsp LiveDetectFourRsp) IsSamePerson() bool {
+	return rsp.IsLive() && rsp.CompareStatus == 0
+}
+
+//LiveDetectFour 检测视频中的用户是否读出了validateData并且为活体。
+//card不为空时，同时比对视频中的人脸和card是否为同一人，card不能是ImageURL
+func (y *Youtu) LiveDetectFour(validateData string, video Video, card Image) (rsp LiveDetectFourRsp, err error) {
+	if video.isZero() {
+		err = ErrEmptyVideo
+		return
+	}
+	if card.IsURL() {
+		err = ErrCardURL
+		return
+	}
+	if card, _, err = y.preprocessImage(card); err != nil {
+		return
+	}
+	req := liveDetectFourReq{
+		AppID:        y.appID(),
+		ValidateData: validateData,
+		Video:        video,
+		Card:         card,
+		CompareFlag:  !card.isZero(),
+	}
+	err = y.serviceRequest(serviceLive, "livedetectfour", req, &rsp)
+	return
+}
+
+type idCardLiveDetectFourReq struct {
+	AppID        string `json:"app_id"`        //App的 API ID
+	IDCardNumber string `json:"idcard_number"` //身份证号码
+	IDCardName   string `json:"idcard_name"`   //身份证姓名
+	ValidateData string `json:"validate_data"` //LiveGetFour获取的唇语验证码
+	Video        Video  `json:"video"`         //使用base64编码的视频数据
+}
+
+//IDCardLiveDetectFourRsp 身份证唇语活体检测返回
+type IDCardLiveDetectFourRsp struct {
+	Status
+	LiveStatus    int     `json:"live_status"`    //活体检测结果，0表示通过
+	LiveMsg       string  `json:"live_msg"`       //活体检测结果描述
+	CompareStatus int     `json:"compare_status"` //与身份证照片比对结果，0表示通过
+	CompareMsg    string  `json:"compare_msg"`    //比对结果描述
+	Sim           float32 `json:"sim"`            //视频中的人脸与身份证照片的相似度
+	VideoPhoto    string  `json:"video_photo"`    //视频中人脸最清晰的一帧，base64编码
+}
+
+//IsLive 是否通过活体检测
+func (rsp IDCardLiveDetectFourRsp) IsLive() bool {
+	return rsp.ErrorCode == 0 && rsp.LiveStatus == 0
+}
+
+//IsSamePerson 视频中的人脸是否与身份证照片为同一人
+func (rsp IDCardLiveDetectFourRsp) IsSamePerson() bool {
+	return rsp.IsLive() && rsp.CompareStatus == 0
+}
+
+//IDCardLiveDetectFour 检测视频中的用户是否为活体，并与身份证号码对应的登记照片比对
+func (y *Youtu) IDCardLiveDetectFour(idCardNumber, idCardName, validateData string, video Video) (rsp IDCardLiveDetectFourRsp, err error) {
+	if video.isZero() {
+		err = ErrEmptyVideo
+		return
+	}
+	req := idCardLiveDetectFourReq{
+		AppID:        y.appID(),
+		IDCardNumber: idCardNumber,
+		IDCardName:   idCardName,
+		ValidateData: validateData,
+		Video:        video,
+	}
+	err = y.serviceRequest(serviceLive, "idcardlivedetectfour", req, &rsp)
+	return
+}
+
+var (
+	//ErrIDCardNameMismatch 身份证号码与姓名不匹配
+	ErrIDCardNameMismatch = errors.New("idcard number and name mismatch")
+	//ErrIDCardNotFound 身份证号码不存在或没有登记照片
+	ErrIDCardNotFound = errors.New("idcard photo not found")
+	//ErrIDCardNoFace 上传的图片中没有检测到人脸
+	ErrIDCardNoFace = errors.New("no face detected in image")
+	//ErrIDCardServiceBusy 身份证照片查询服务繁忙，可稍后重试
+	ErrIDCardServiceBusy = errors.New("idcard service busy")
+)
+
+//idCardCompareErrors 身份证人脸比对接口返回码对应的错误
+var idCardCompareErrors = map[int]error{
+	-5001: ErrIDCardNameMismatch,
+	-5002: ErrIDCardNotFound,
+	-5003: ErrIDCardServiceBusy,
+	-1101: ErrIDCardNoFace,
+}
+
+type idCardFaceCompareReq struct {
+	AppID        string `json:"app_id"`          //App的 API ID
+	IDCardNumber string `json:"idcard_number"`   //身份证号码
+	IDCardName   string `json:"idcard_name"`     //身份证姓名
+	Image        Image  `json:"image,omitempty"` //使用base64编码的二进制图片数据
+	URL          string `json:"url,omitempty"`   //图片的url, image和url只需提供一个
+}
+
+//IDCardFaceCompareRsp 身份证人脸比对返回
+type IDCardFaceCompareRsp struct {
+	Status
+	Similarity float32 `json:"similarity"` //图片中的人脸与身份证登记照片的相似度
+	FailFlag   int     `json:"fail_flag"`  //比对失败的原因，0表示比对成功
+}
+
+//Err 将返回状态码转换为错误，比对成功时返回nil。
+//已知的状态码返回ErrIDCard*，其他返回*APIError
+func (rsp IDCardFaceCompareRsp) Err() error {
+	if err, ok := idCardCompareErrors[rsp.ErrorCode]; ok {
+		return err
+	}
+	return rsp.Status.Err()
+}
+
+//IDCardFaceCompare 比对图片中的人脸与身份证号码对应的登记照片，返回相似度。
+//比对失败时err与rsp.Err()相同
+func (y *Youtu) IDCardFaceCompare(idCardNumber, idCardName string, image Image) (rsp IDCardFaceCompareRsp, err error) {
+	if image, _, err = y.preprocessImage(image); err != nil {
+		return
+	}
+	data, url, err := image.fields()
+	if err != nil {
+		return
+	}
+	req := idCardFaceCompareReq{
+		AppID:        y.appID(),
+		IDCardNumber: idCardNumber,
+		IDCardName:   idCardName,
+		Image:        data,
+		URL:          url,
+	}
+	err = y.serviceRequest(serviceLive, "idcardfacecompare", req, &rsp)
+	return
+}
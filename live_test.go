@@ -0,0 +1,93 @@
+/*
+* File Name:	live_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-21
+ */
+
+package youtu
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestLiveGetFour(t *testing.T) {
+	rsp, err := yt.LiveGetFour()
+	if err != nil {
+		t.Errorf("LiveGetFour failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestLiveDetectFour(t *testing.T) {
+	video := []byte("fake video data")
+	requests := 0
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/youtu/openliveapi/livedetectfour" {
+			t.Errorf("path = %s\n", r.URL.Path)
+		}
+		if req["video"] != base64.StdEncoding.EncodeToString(video) || req["compare_flag"] != true || req["card"] == nil {
+			t.Errorf("req: validate_data %v, compare_flag %v\n", req["validate_data"], req["compare_flag"])
+		}
+		w.Write([]byte(`{"live_status":0,"compare_status":0,"sim":87}`))
+	})
+	defer ts.Close()
+
+	rsp, err := y.LiveDetectFour("1234", VideoReader(bytes.NewReader(video)), ImageFile(testDataDir+"imageA.jpg"))
+	if err != nil {
+		t.Errorf("LiveDetectFour failed: %s\n", err)
+		return
+	}
+	if !rsp.IsLive() || !rsp.IsSamePerson() || rsp.Sim != 87 {
+		t.Errorf("rsp: %#v\n", rsp)
+	}
+
+	if _, err = y.IDCardLiveDetectFour("id", "name", "1234", VideoData(nil)); err != ErrEmptyVideo {
+		t.Errorf("IDCardLiveDetectFour err = %v, want %v\n", err, ErrEmptyVideo)
+	}
+	if _, err = y.LiveDetectFour("1234", VideoData(video), ImageURL("http://example.com/a.jpg")); err != ErrCardURL {
+		t.Errorf("LiveDetectFour err = %v, want %v\n", err, ErrCardURL)
+	}
+	//超过VideoMaxSize的视频在发送前返回错误
+	large := VideoData(make([]byte, VideoMaxSize+1))
+	if _, err = y.LiveDetectFour("1234", large, Image{}); err != ErrVideoTooLarge || requests != 1 {
+		t.Errorf("LiveDetectFour err = %v, requests %d, want %v\n", err, requests, ErrVideoTooLarge)
+	}
+}
+
+func TestIDCardFaceCompare(t *testing.T) {
+	rsp, err := yt.IDCardFaceCompare("110000190001010000", "ochapman", ImageFile(testDataDir+"imageA.jpg"))
+	if err != nil {
+		t.Errorf("IDCardFaceCompare failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v, err: %v\n", rsp, rsp.Err())
+}
+
+func TestIDCardFaceCompareErr(t *testing.T) {
+	tests := []struct {
+		rsp IDCardFaceCompareRsp
+		err error
+	}{
+		{IDCardFaceCompareRsp{Similarity: 90}, nil},
+		{IDCardFaceCompareRsp{Status: Status{ErrorCode: -5001}}, ErrIDCardNameMismatch},
+		{IDCardFaceCompareRsp{Status: Status{ErrorCode: -5002}}, ErrIDCardNotFound},
+	}
+	for _, tt := range tests {
+		if err := tt.rsp.Err(); err != tt.err {
+			t.Errorf("Err() = %v, want %v\n", err, tt.err)
+		}
+	}
+	err := IDCardFaceCompareRsp{Status: Status{ErrorCode: -1, ErrorMsg: "unknown"}}.Err()
+	if e, ok := err.(*APIError); !ok || e.Code != -1 {
+		t.Errorf("Err() = %#v\n", err)
+	}
+}
@@ -0,0 +1,270 @@
+/*
+* File Name:	migrate.go
+* Description:  在优图App之间迁移人脸库
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-03
+ */
+
+package youtu
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+//MigrateOptions 迁移选项
+type MigrateOptions struct {
+	Checkpoint  string //检查点文件，中断后再次迁移时跳过已完成的部分，为空时不保存。迁移过程中的进度记录在Checkpoint+".log"中
+	Concurrency int    //读取源人脸库时同时进行的请求数，见Export
+}
+
+//MigrateReport 迁移结果，同时作为检查点保存
+type MigrateReport struct {
+	Faces   map[string]string `json:"faces"`   //源face_id -> 新face_id
+	Persons map[string]bool   `json:"persons"` //已完成迁移的个体
+	Missing []string          `json:"missing"` //本地没有图片而跳过的源face_id
+}
+
+//NewFaceID 返回源face_id在新App中对应的face_id
+func (r *MigrateReport) NewFaceID(faceID string) (string, bool) {
+	id, ok := r.Faces[faceID]
+	return id, ok
+}
+
+//migrateLogEntry 检查点日志中的一条记录，记录上次保存检查点之后的进度
+type migrateLogEntry struct {
+	Face    string `json:"face,omitempty"`     //完成迁移的源face_id
+	NewFace string `json:"new_face,omitempty"` //对应的新face_id
+	Person  string `json:"person,omitempty"`   //完成迁移的个体
+	Missing string `json:"missing,omitempty"`  //本地没有图片的源face_id
+}
+
+func (e migrateLogEntry) apply(r *MigrateReport) {
+	if e.Face != "" {
+		r.Faces[e.Face] = e.NewFace
+	}
+	if e.Person != "" {
+		r.Persons[e.Person] = true
+	}
+	if e.Missing != "" {
+		r.Missing = appendUnique(r.Missing, e.Missing)
+	}
+}
+
+//loadMigrateReport 读取检查点并合并检查点日志，文件不存在时返回空的结果
+func loadMigrateReport(path string) (*MigrateReport, error) {
+	r := &MigrateReport{}
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			if err = json.Unmarshal(data, r); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if r.Faces == nil {
+		r.Faces = make(map[string]string)
+	}
+	if r.Persons == nil {
+		r.Persons = make(map[string]bool)
+	}
+	if path == "" {
+		return r, nil
+	}
+	f, err := os.OpenFile(path+".log", os.O_RDWR, 0644)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	var offset int64
+	for {
+		line, err := br.ReadBytes('\n')
+		var e migrateLogEntry
+		if err != nil || json.Unmarshal(line, &e) != nil {
+			//中断时写入的不完整的最后一行，截断以免之后追加的记录接在后面
+			if err = f.Truncate(offset); err != nil {
+				return nil, err
+			}
+			return r, nil
+		}
+		offset += int64(len(line))
+		e.apply(r)
+	}
+}
+
+//Migrate 将src中的组、个体(名字、备注信息)和人脸迁移到dst。
+//优图不能下载已加入的人脸图片，faces需要按照src中的face_id提供原图，没有图片的人脸被跳过并记录在Missing中。
+//每个个体和人脸成功后追加到检查点日志，迁移结束时合并为检查点；中断后使用相同的检查点再次调用时，
+//dst中已经存在但没有完成的个体会被删除并重新创建。
+//个体失败时继续迁移其他个体并返回*GroupError，读取faces出错(不包括图片不存在)时中止迁移
+func Migrate(src, dst *Youtu, faces FaceImages, opt MigrateOptions) (report *MigrateReport, err error) {
+	report, err = loadMigrateReport(opt.Checkpoint)
+	if err != nil {
+		return
+	}
+	snap, err := src.snapshot(opt.Concurrency, false)
+	if err != nil {
+		return
+	}
+	m := &migration{dst: dst, faces: faces, report: report, checkpoint: opt.Checkpoint}
+	defer m.closeLog()
+	gerr := &GroupError{Op: "Migrate", Failed: make(map[string]error)}
+	for _, p := range snap.Persons {
+		if report.Persons[p.PersonID] {
+			continue
+		}
+		if err = m.person(p); err != nil {
+			if m.abort != nil {
+				return report, m.abort
+			}
+			gerr.Failed[p.PersonID] = err
+			continue
+		}
+		gerr.Done = append(gerr.Done, p.PersonID)
+	}
+	sort.Strings(report.Missing)
+	if err = m.save(); err != nil {
+		return
+	}
+	return report, gerr.err()
+}
+
+//migration 一次迁移的状态
+type migration struct {
+	dst        *Youtu
+	faces      FaceImages
+	report     *MigrateReport
+	checkpoint string
+	log        *os.File //检查点日志，避免每个人脸完成后重写整个检查点
+	abort      error    //读取图片或保存检查点失败，需要中止迁移
+}
+
+//record 更新迁移结果并追加到检查点日志
+func (m *migration) record(e migrateLogEntry) error {
+	e.apply(m.report)
+	if m.checkpoint == "" {
+		return nil
+	}
+	if m.log == nil {
+		f, err := os.OpenFile(m.checkpoint+".log", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			m.abort = err
+			return err
+		}
+		m.log = f
+	}
+	data, err := json.Marshal(e)
+	if err == nil {
+		_, err = m.log.Write(append(data, '\n'))
+	}
+	if err != nil {
+		m.abort = err
+	}
+	return err
+}
+
+func (m *migration) closeLog() {
+	if m.log != nil {
+		m.log.Close()
+		m.log = nil
+	}
+}
+
+//save 保存完整的检查点并删除已经合并的检查点日志
+func (m *migration) save() error {
+	if m.checkpoint == "" {
+		return nil
+	}
+	m.closeLog()
+	if err := writeJSONFile(m.checkpoint, m.report); err != nil {
+		m.abort = err
+		return err
+	}
+	if err := os.Remove(m.checkpoint + ".log"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+//image 返回源face_id对应的图片，图片不存在时ok为false
+func (m *migration) image(faceID string) (img Image, ok bool, err error) {
+	img, err = m.faces.FaceImage(faceID)
+	if err == ErrNoFaceImage || os.IsNotExist(err) {
+		return img, false, m.record(migrateLogEntry{Missing: faceID})
+	}
+	return img, err == nil, err
+}
+
+//person 在dst中创建个体并逐个加入人脸，每个人脸成功后记录到检查点日志
+func (m *migration) person(p SnapshotPerson) error {
+	created := false
+	for _, face := range p.Faces {
+		if _, ok := m.report.Faces[face.FaceID]; ok {
+			created = true
+		}
+	}
+	for _, face := range p.Faces {
+		if _, ok := m.report.Faces[face.FaceID]; ok {
+			continue
+		}
+		img, ok, err := m.image(face.FaceID)
+		if err != nil {
+			m.abort = err
+			return err
+		}
+		if !ok {
+			continue
+		}
+		var newFaceID string
+		if !created {
+			//检查点中没有该个体的人脸，dst中已有的个体是中断前没有记录的，重新创建
+			if _, err = m.dst.GetInfo(p.PersonID); err == nil {
+				if _, err = m.dst.DelPerson(p.PersonID); err != nil {
+					return err
+				}
+			}
+			rsp, err := m.dst.NewPerson(p.PersonID, p.PersonName, p.GroupIDs, img, p.Tag)
+			if err != nil {
+				return err
+			}
+			created, newFaceID = true, rsp.FaceID
+		} else {
+			rsp, err := m.dst.AddFace(p.PersonID, []Image{img}, "")
+			if err != nil {
+				return err
+			}
+			if len(rsp.FaceIDs) != 1 {
+				return fmt.Errorf("face %s: no face added", face.FaceID)
+			}
+			newFaceID = rsp.FaceIDs[0]
+		}
+		if err = m.record(migrateLogEntry{Face: face.FaceID, NewFace: newFaceID}); err != nil {
+			return err
+		}
+	}
+	if !created {
+		return ErrNoFaceImage
+	}
+	return m.record(migrateLogEntry{Person: p.PersonID})
+}
+
+//appendUnique 在ss中没有s时加入
+func appendUnique(ss []string, s string) []string {
+	for _, v := range ss {
+		if v == s {
+			return ss
+		}
+	}
+	return append(ss, s)
+}
@@ -0,0 +1,126 @@
+/*
+* File Name:	migrate_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-03
+ */
+
+package youtu
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//failingFaces 读取n张图片后返回错误，模拟迁移中断
+type failingFaces struct {
+	FaceImages
+	n int
+}
+
+func (f *failingFaces) FaceImage(faceID string) (Image, error) {
+	if f.n == 0 {
+		return Image{}, errors.New("disk error")
+	}
+	f.n--
+	return f.FaceImages.FaceImage(faceID)
+}
+
+func TestMigrate(t *testing.T) {
+	src, _, srcDone := newFakeYoutu()
+	defer srcDone()
+	dst, g, dstDone := newFakeYoutu()
+	defer dstDone()
+	img := ImageFile(testDataDir + "imageA.jpg")
+	src.NewPerson("alice", "Alice", []string{"dev"}, img, "E001")
+	src.AddFace("alice", []Image{img, img}, "")
+	src.NewPerson("bob", "Bob", []string{"dev", "ops"}, img, "")
+	src.AddFace("bob", []Image{img}, "")
+	//bob的第二张图片(face5)不在本地
+	faces := newFaceImageDir(t, "face1", "face2", "face3", "face4")
+	defer os.RemoveAll(string(faces))
+	checkpoint := filepath.Join(string(faces), "checkpoint.json")
+
+	//读取两张图片后中断
+	_, err := Migrate(src, dst, &failingFaces{faces, 2}, MigrateOptions{Checkpoint: checkpoint})
+	if err == nil || err.Error() != "disk error" {
+		t.Fatalf("Migrate err = %v, want disk error\n", err)
+	}
+	if len(g.persons["alice"].faces) != 2 {
+		t.Errorf("alice before resume: %#v\n", g.persons["alice"])
+	}
+	//中断时进度只在检查点日志中
+	if _, err := os.Stat(checkpoint + ".log"); err != nil {
+		t.Errorf("checkpoint log: %v\n", err)
+	}
+
+	report, err := Migrate(src, dst, faces, MigrateOptions{Checkpoint: checkpoint})
+	if err != nil {
+		t.Fatalf("Migrate failed: %s\n", err)
+	}
+	if g.calls["newperson"] != 2 {
+		t.Errorf("newperson calls = %d, want 2\n", g.calls["newperson"])
+	}
+	alice, bob := g.persons["alice"], g.persons["bob"]
+	if alice.tag != "E001" || len(alice.faces) != 3 || !sameStrings(bob.groups, []string{"dev", "ops"}) || len(bob.faces) != 1 {
+		t.Errorf("alice: %#v, bob: %#v\n", alice, bob)
+	}
+	if len(report.Faces) != 4 || len(report.Missing) != 1 || report.Missing[0] != "face5" {
+		t.Errorf("report: %#v\n", report)
+	}
+	if id, ok := report.NewFaceID("face4"); !ok || id != bob.faces[0] {
+		t.Errorf("NewFaceID(face4) = %s, %v\n", id, ok)
+	}
+
+	//检查点中已完成的个体不会再次迁移
+	if _, err = Migrate(src, dst, faces, MigrateOptions{Checkpoint: checkpoint}); err != nil || g.calls["newperson"] != 2 {
+		t.Errorf("Migrate again: %v, calls: %v\n", err, g.calls)
+	}
+	if data, err := ioutil.ReadFile(checkpoint); err != nil || len(data) == 0 {
+		t.Errorf("checkpoint: %s, %v\n", data, err)
+	}
+	if _, err := os.Stat(checkpoint + ".log"); !os.IsNotExist(err) {
+		t.Errorf("checkpoint log not removed: %v\n", err)
+	}
+}
+
+func TestLoadMigrateReportLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "youtu")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	checkpoint := filepath.Join(dir, "checkpoint.json")
+	if err = writeJSONFile(checkpoint, &MigrateReport{Faces: map[string]string{"f1": "n1"}}); err != nil {
+		t.Fatalf("writeJSONFile failed: %s", err)
+	}
+	//日志合并到检查点之上，不完整的最后一行被忽略
+	log := `{"face":"f2","new_face":"n2"}` + "\n" + `{"person":"alice"}` + "\n" + `{"missing":"f3"}` + "\n" + `{"face":"f4","ne`
+	if err = ioutil.WriteFile(checkpoint+".log", []byte(log), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %s", err)
+	}
+	r, err := loadMigrateReport(checkpoint)
+	if err != nil {
+		t.Fatalf("loadMigrateReport failed: %s", err)
+	}
+	if len(r.Faces) != 2 || r.Faces["f2"] != "n2" || !r.Persons["alice"] || len(r.Missing) != 1 || r.Missing[0] != "f3" {
+		t.Errorf("report: %#v", r)
+	}
+
+	//不完整的行被截断，之后追加的记录在下次读取时有效
+	f, err := os.OpenFile(checkpoint+".log", os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %s", err)
+	}
+	f.Write([]byte(`{"face":"f5","new_face":"n5"}` + "\n"))
+	f.Close()
+	if r, err = loadMigrateReport(checkpoint); err != nil {
+		t.Fatalf("loadMigrateReport failed: %s", err)
+	}
+	if len(r.Faces) != 3 || r.Faces["f5"] != "n5" {
+		t.Errorf("report after append: %#v", r)
+	}
+}
@@ -7,8 +7,10 @@
 package youtu
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -16,23 +18,122 @@ import (
 	"time"
 )
 
-func (y *Youtu) interfaceURL(ifname string) string {
-	return fmt.Sprintf("http://%s/youtu/api/%s", y.host, ifname)
+//优图各类服务的接口路径
+const (
+	serviceFace  = "api"         //人脸服务
+	serviceOCR   = "ocrapi"      //OCR服务
+	serviceImage = "imageapi"    //图像识别服务
+	serviceLive  = "openliveapi" //活体检测服务
+	serviceCar   = "carapi"      //车辆识别服务
+)
+
+func (y *Youtu) interfaceURL(service, ifname string) string {
+	return fmt.Sprintf("http://%s/youtu/%s/%s", y.host, service, ifname)
+}
+
+//interfaceRequest 请求人脸服务接口
+func (y *Youtu) interfaceRequest(ifname string, req, rsp interface{}) error {
+	return y.serviceRequest(serviceFace, ifname, req, rsp)
+}
+
+//Call 请求库中还没有封装的接口，endpoint为"服务/接口名"，如"imageapi/imagetag"，
+//不带服务时为人脸服务的接口。req可以是结构体或map，编码为JSON，需要带上app_id(见AppID)，可以包含Image/Video值；
+//rsp可以是嵌入了Status的结构体、map或者*json.RawMessage，返回非0状态码时err为*APIError
+func (y *Youtu) Call(ctx context.Context, endpoint string, req, rsp interface{}) error {
+	service, ifname := splitEndpoint(endpoint)
+	return y.request(ctx, service, ifname, req, rsp)
+}
+
+//splitEndpoint 将"服务/接口名"分为服务和接口名，不带服务时为人脸服务
+func splitEndpoint(endpoint string) (service, ifname string) {
+	service, ifname = serviceFace, strings.Trim(endpoint, "/")
+	if i := strings.LastIndex(ifname, "/"); i >= 0 {
+		service, ifname = ifname[:i], ifname[i+1:]
+	}
+	return
+}
+
+func (y *Youtu) serviceRequest(service, ifname string, req, rsp interface{}) error {
+	return y.request(y.context(), service, ifname, req, rsp)
+}
+
+//WithContext 返回使用ctx发送请求的Youtu副本，ctx取消时副本中正在进行和之后的请求返回错误。
+//副本与y共享签名、host和频率限制
+func (y *Youtu) WithContext(ctx context.Context) *Youtu {
+	c := *y
+	c.ctx = ctx
+	return &c
+}
+
+func (y *Youtu) context() context.Context {
+	if y.ctx != nil {
+		return y.ctx
+	}
+	return context.Background()
 }
 
-func (y *Youtu) interfaceRequest(ifname string, req, rsp interface{}) (err error) {
-	url := y.interfaceURL(ifname)
+func (y *Youtu) request(ctx context.Context, service, ifname string, req, rsp interface{}) (err error) {
+	url := y.interfaceURL(service, ifname)
 	if y.debug {
 		fmt.Printf("req: %#v\n", req)
 	}
-	data, err := json.Marshal(req)
+	//在发送请求之前校验图片，避免无效请求消耗调用次数
+	if err = validateRequest(req); err != nil {
+		return
+	}
+	key, ttl, err := y.cache.key(service, ifname, req)
 	if err != nil {
 		return
 	}
-	body, err := y.get(url, string(data))
+	if body, ok := y.cache.get(key); ok {
+		return y.decode(append([]byte(nil), body...), rsp)
+	}
+	fetch := func() ([]byte, error) {
+		return y.fetch(ctx, url, req)
+	}
+	var body []byte
+	if y.flights.coalesce(service, ifname) {
+		//相同的请求共享一次接口调用，没有缓存时单独计算key
+		if key == "" {
+			if key, err = requestKey(service+"/"+ifname, req); err != nil {
+				return
+			}
+		}
+		var shared bool
+		if body, shared, err = y.flights.do(ctx, key, fetch); shared {
+			body = append([]byte(nil), body...)
+		}
+	} else {
+		body, err = fetch()
+	}
 	if err != nil {
 		return
 	}
+	if err = y.decode(body, rsp); err != nil {
+		return
+	}
+	y.cache.set(key, body, ttl)
+	y.cache.update(service, ifname)
+	return
+}
+
+//fetch 发送请求并读取返回
+func (y *Youtu) fetch(ctx context.Context, url string, req interface{}) ([]byte, error) {
+	if err := y.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	//请求体边编码边发送，图片数据不需要整体读入内存
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(encodeJSON(pw, req))
+	}()
+	body, err := y.get(ctx, url, pr)
+	pr.Close()
+	return body, err
+}
+
+//decode 解析接口返回，返回非0状态码时同时返回错误
+func (y *Youtu) decode(body []byte, rsp interface{}) (err error) {
 	err = json.Unmarshal(body, &rsp)
 	if err != nil {
 		if y.debug {
@@ -40,17 +141,22 @@ func (y *Youtu) interfaceRequest(ifname string, req, rsp interface{}) (err error
 		}
 		return fmt.Errorf("json.Unmarshal() rsp: %s failed: %s\n", rsp, err)
 	}
-	return
+	if s, ok := rsp.(rawSetter); ok && y.keepRaw {
+		s.setRaw(body)
+	}
+	//返回非0状态码时同时返回错误，rsp中保留完整的返回内容
+	return rspError(rsp, body)
 }
 
-func (y *Youtu) get(addr string, req string) (rsp []byte, err error) {
+func (y *Youtu) get(ctx context.Context, addr string, req io.Reader) (rsp []byte, err error) {
 	client := &http.Client{
 		Timeout: time.Duration(5 * time.Second),
 	}
-	httpreq, err := http.NewRequest("POST", addr, strings.NewReader(req))
+	httpreq, err := http.NewRequest("POST", addr, req)
 	if err != nil {
 		return
 	}
+	httpreq = httpreq.WithContext(ctx)
 	auth := y.sign()
 	if y.debug {
 		fmt.Fprintf(os.Stderr, "Authorization: %s\n", auth)
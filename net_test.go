@@ -0,0 +1,217 @@
+/*
+* File Name:	net_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-05
+ */
+
+package youtu
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+//newTestYoutu 返回连接到本地测试服务器的Youtu
+func newTestYoutu(handler http.HandlerFunc) (*Youtu, *httptest.Server) {
+	ts := httptest.NewServer(handler)
+	return Init(as, strings.TrimPrefix(ts.URL, "http://")), ts
+}
+
+func TestInterfaceRequestStream(t *testing.T) {
+	imgData, err := ioutil.ReadFile(testDataDir + "imageA.jpg")
+	if err != nil {
+		t.Errorf("ReadFile failed: %s\n", err)
+		return
+	}
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/youtu/api/detectface" {
+			t.Errorf("path = %s\n", r.URL.Path)
+		}
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Decode request failed: %s\n", err)
+		}
+		if req["image"] != base64.StdEncoding.EncodeToString(imgData) {
+			t.Errorf("image = %v\n", req["image"])
+		}
+		w.Write([]byte(`{"session_id":"s1","face":[{"face_id":"f1","x":10}]}`))
+	})
+	defer ts.Close()
+
+	rsp, err := y.DetectFace(ImageReader(bytes.NewReader(imgData)), false)
+	if err != nil {
+		t.Errorf("DetectFace failed: %s\n", err)
+		return
+	}
+	if rsp.SessionID != "s1" || len(rsp.Face) != 1 || rsp.Face[0].X != 10 {
+		t.Errorf("rsp: %#v\n", rsp)
+	}
+}
+
+func TestFaceIdentifyCandidates(t *testing.T) {
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		if _, ok := req["group_id"]; ok || req["topn"] != 3.0 {
+			t.Errorf("req: %v\n", req)
+		}
+		w.Write([]byte(`{"candidates":[{"person_id":"b","confidence":60},{"person_id":"a","confidence":90},{"person_id":"c","confidence":75}]}`))
+	})
+	defer ts.Close()
+
+	rsp, err := y.FaceIdentifyTopN([]string{"g1", "g2"}, ImageFile(testDataDir+"imageA.jpg"), 3)
+	if err != nil {
+		t.Errorf("FaceIdentifyTopN failed: %s\n", err)
+		return
+	}
+	var ids string
+	for _, c := range rsp.Candidates {
+		ids += c.PersonID
+	}
+	if ids != "acb" {
+		t.Errorf("candidates not sorted: %s\n", ids)
+	}
+}
+
+func TestMultiFaceIdentifyResults(t *testing.T) {
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/youtu/api/multifaceidentify" || req["topn"] != 2.0 || len(req["group_ids"].([]interface{})) != 2 {
+			t.Errorf("path %s, req: %v\n", r.URL.Path, req)
+		}
+		w.Write([]byte(`{"group_size":3,"time_ms":12,"results":[` +
+			`{"face_rect":{"x":10,"y":20,"width":30,"height":40},"candidates":[{"person_id":"b","face_id":"f2","confidence":60,"tag":"t"},{"person_id":"a","face_id":"f1","confidence":90}]},` +
+			`{"face_rect":{"x":50,"y":5,"width":15,"height":15},"candidates":[{"person_id":"c","confidence":70}]}]}`))
+	})
+	defer ts.Close()
+	y.SetPreprocess(&Preprocess{MaxSide: 100})
+
+	rsp, err := y.MultiFaceIdentify([]string{"g1", "g2"}, ImageData(encodeTestImage(t, FormatPNG, 200, 100)), 2)
+	if err != nil {
+		t.Fatalf("MultiFaceIdentify failed: %s\n", err)
+	}
+	if rsp.GroupSize != 3 || rsp.TimeMs != 12 || len(rsp.Results) != 2 {
+		t.Fatalf("rsp: %#v\n", rsp)
+	}
+	//人脸框换算回原图坐标，候选人按置信度排序
+	r := rsp.Results[0]
+	if r.FaceRect.X != 20 || r.FaceRect.Y != 40 || r.FaceRect.Width != 60 || r.FaceRect.Height != 80 {
+		t.Errorf("face_rect: %#v\n", r.FaceRect)
+	}
+	if len(r.Candidates) != 2 || r.Candidates[0].PersonID != "a" || r.Candidates[0].FaceID != "f1" ||
+		r.Candidates[1].PersonID != "b" || r.Candidates[1].Tag != "t" {
+		t.Errorf("candidates: %#v\n", r.Candidates)
+	}
+	r = rsp.Results[1]
+	if r.FaceRect.X != 100 || r.FaceRect.Width != 30 || len(r.Candidates) != 1 || r.Candidates[0].Confidence != 70 {
+		t.Errorf("result 1: %#v\n", r)
+	}
+}
+
+func TestInterfaceRequestStatus(t *testing.T) {
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/youtu/api/delface":
+			w.Write([]byte(`{"session_id":"s1","deleted":0,"errorcode":-1303,"errormsg":"person not exist"}`))
+		case "/youtu/api/getgroupids":
+			w.Write([]byte(`{"group_ids":["g1"],"errorcode":0,"errormsg":"OK"}`))
+		}
+	})
+	defer ts.Close()
+
+	rsp, err := y.DelFace("nobody", []string{"f1"})
+	e, ok := err.(*APIError)
+	if !ok || e.Code != -1303 || e.Msg != "person not exist" {
+		t.Errorf("DelFace err = %#v\n", err)
+	}
+	if rsp.OK() || rsp.SessionID != "s1" || rsp.Err() == nil {
+		t.Errorf("rsp: %#v\n", rsp)
+	}
+
+	groups, err := y.GetGroupIDs()
+	if err != nil || !groups.OK() || len(groups.GroupIDs) != 1 {
+		t.Errorf("GetGroupIDs: %#v, %v\n", groups, err)
+	}
+}
+
+func TestCall(t *testing.T) {
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/youtu/imageapi/newfeature":
+			var req map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&req)
+			if req["app_id"] != "1000061" || req["image"] == "" {
+				t.Errorf("req: %v\n", req)
+			}
+			w.Write([]byte(`{"session_id":"s1","score":80,"errorcode":0,"errormsg":"OK"}`))
+		case "/youtu/imageapi/mapfeature":
+			var req map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&req)
+			data, _ := ioutil.ReadFile(testDataDir + "imageA.jpg")
+			if req["image"] != base64.StdEncoding.EncodeToString(data) {
+				t.Errorf("map req image: %.40v\n", req["image"])
+			}
+			w.Write([]byte(`{"errorcode":0}`))
+		case "/youtu/api/getgroupids":
+			w.Write([]byte(`{"errorcode":-1,"errormsg":"failed"}`))
+		}
+	})
+	defer ts.Close()
+	y.SetKeepRaw(true)
+
+	req := struct {
+		AppID string `json:"app_id"`
+		Image Image  `json:"image"`
+	}{y.AppID(), ImageFile(testDataDir + "imageA.jpg")}
+	var rsp struct {
+		Status
+		Score int `json:"score"`
+	}
+	if err := y.Call(context.Background(), "imageapi/newfeature", req, &rsp); err != nil {
+		t.Errorf("Call failed: %s\n", err)
+	}
+	if rsp.Score != 80 || rsp.SessionID != "s1" || !bytes.Contains(rsp.Raw, []byte(`"score":80`)) {
+		t.Errorf("rsp: %#v\n", rsp)
+	}
+
+	//map请求中的图片同样以base64编码
+	mapReq := map[string]interface{}{"app_id": y.AppID(), "image": ImageFile(testDataDir + "imageA.jpg")}
+	if err := y.Call(context.Background(), "imageapi/mapfeature", mapReq, &rsp); err != nil {
+		t.Errorf("Call with map failed: %s\n", err)
+	}
+
+	//没有嵌入Status的返回也会转换状态码
+	m := make(map[string]interface{})
+	err := y.Call(context.Background(), "getgroupids", map[string]string{"app_id": y.AppID()}, &m)
+	if e, ok := err.(*APIError); !ok || e.Code != -1 || m["errormsg"] != "failed" {
+		t.Errorf("Call err = %#v, rsp = %v\n", err, m)
+	}
+
+	//未设置SetKeepRaw时不保留原始返回
+	y.SetKeepRaw(false)
+	groups, _ := y.GetGroupIDs()
+	if groups.Raw != nil {
+		t.Errorf("Raw = %s\n", groups.Raw)
+	}
+}
+
+func TestCallContext(t *testing.T) {
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	defer ts.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := y.Call(ctx, "getgroupids", map[string]string{}, nil); err == nil {
+		t.Errorf("Call with canceled context succeeded\n")
+	}
+}
@@ -0,0 +1,294 @@
+/*
+* File Name:	ocr.go
+* Description:  http://open.youtu.qq.com OCR API
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-15
+ */
+
+package youtu
+
+import (
+	"math"
+	"reflect"
+)
+
+//cardType 身份证正反面
+type cardType int
+
+const (
+	//cardTypeFront 身份证正面
+	cardTypeFront cardType = iota
+	//cardTypeBack 身份证反面
+	cardTypeBack
+)
+
+func idCardType(isBack bool) cardType {
+	if isBack {
+		return cardTypeBack
+	}
+	return cardTypeFront
+}
+
+//ItemCoord 识别出的文字所在区域
+type ItemCoord struct {
+	X      int `json:"x"`      //区域左上角x
+	Y      int `json:"y"`      //区域左上角y
+	Width  int `json:"width"`  //区域宽度
+	Height int `json:"height"` //区域高度
+}
+
+//scale 将区域换算回原图坐标
+func (c *ItemCoord) scale(s float64) {
+	if s == 1 {
+		return
+	}
+	c.X = int(math.Round(float64(c.X) * s))
+	c.Y = int(math.Round(float64(c.Y) * s))
+	c.Width = int(math.Round(float64(c.Width) * s))
+	c.Height = int(math.Round(float64(c.Height) * s))
+}
+
+//Word 识别出的单个字符
+type Word struct {
+	Character  string  `json:"character"`  //字符
+	Confidence float32 `json:"confidence"` //置信度
+}
+
+//OCRItem 识别出的字段或文本行
+type OCRItem struct {
+	Item       string    `json:"item"`       //字段名称，通用OCR中为空
+	ItemString string    `json:"itemstring"` //识别出的文字
+	ItemConf   float32   `json:"itemconf"`   //字段的置信度
+	ItemCoord  ItemCoord `json:"itemcoord"`  //文字所在区域
+	Words      []Word    `json:"words"`      //每个字符的识别结果，只在通用OCR中返回
+}
+
+//licenseType 驾驶证OCR接口识别的证件类型
+type licenseType int
+
+const (
+	//licenseTypeVehicle 行驶证
+	licenseTypeVehicle licenseType = iota
+	//licenseTypeDriver 驾驶证
+	licenseTypeDriver
+)
+
+//scaleItems 将识别结果中的区域换算回原图坐标
+func scaleItems(items []OCRItem, s float64) {
+	for i := range items {
+		items[i].ItemCoord.scale(s)
+	}
+}
+
+type idCardOCRReq struct {
+	AppID    string   `json:"app_id"`          //App的 API ID
+	Image    Image    `json:"image,omitempty"` //使用base64编码的二进制图片数据
+	URL      string   `json:"url,omitempty"`   //图片的url, image和url只需提供一个
+	CardType cardType `json:"card_type"`       //0/1 身份证正面/反面
+}
+
+//IDCardOCRRsp 身份证OCR返回，*Conf为对应字段每个字符的置信度
+type IDCardOCRRsp struct {
+	Status
+	Name          string `json:"name"`                      //姓名(正面)
+	NameConf      []int  `json:"name_confidence_all"`       //姓名置信度
+	Sex           string `json:"sex"`                       //性别(正面)
+	SexConf       []int  `json:"sex_confidence_all"`        //性别置信度
+	Nation        string `json:"nation"`                    //民族(正面)
+	NationConf    []int  `json:"nation_confidence_all"`     //民族置信度
+	Birth         string `json:"birth"`                     //出生日期(正面)
+	BirthConf     []int  `json:"birth_confidence_all"`      //出生日期置信度
+	Address       string `json:"address"`                   //地址(正面)
+	AddressConf   []int  `json:"address_confidence_all"`    //地址置信度
+	ID            string `json:"id"`                        //身份证号(正面)
+	IDConf        []int  `json:"id_confidence_all"`         //身份证号置信度
+	Authority     string `json:"authority"`                 //签发机关(反面)
+	AuthorityConf []int  `json:"authority_confidence_all"`  //签发机关置信度
+	ValidDate     string `json:"valid_date"`                //有效期限(反面)
+	ValidDateConf []int  `json:"valid_date_confidence_all"` //有效期限置信度
+}
+
+//IDCardOCR 识别身份证图片中的文字，isBack为true时识别身份证反面
+func (y *Youtu) IDCardOCR(image Image, isBack bool) (rsp IDCardOCRRsp, err error) {
+	if image, _, err = y.preprocessImage(image); err != nil {
+		return
+	}
+	data, url, err := image.fields()
+	if err != nil {
+		return
+	}
+	req := idCardOCRReq{
+		AppID:    y.appID(),
+		Image:    data,
+		URL:      url,
+		CardType: idCardType(isBack),
+	}
+	err = y.serviceRequest(serviceOCR, "idcardocr", req, &rsp)
+	return
+}
+
+//OCRRsp OCR返回
+type OCRRsp struct {
+	Status
+	Items []OCRItem `json:"items"` //识别出的字段或文本行
+}
+
+//ocr 请求OCR服务中只需要图片的接口，返回结果中的区域已换算回原图坐标
+func (y *Youtu) ocr(ifname string, image Image) (rsp OCRRsp, err error) {
+	scale, err := y.imageRequest(serviceOCR, ifname, image, &rsp)
+	scaleItems(rsp.Items, scale)
+	return
+}
+
+//NameCardOCR 识别名片图片中的姓名、电话、公司等字段
+func (y *Youtu) NameCardOCR(image Image) (rsp OCRRsp, err error) {
+	return y.ocr("namecardocr", image)
+}
+
+//GeneralOCR 识别图片中的文本行，返回每行文字的区域以及每个字符的置信度
+func (y *Youtu) GeneralOCR(image Image) (rsp OCRRsp, err error) {
+	return y.ocr("generalocr", image)
+}
+
+//Field 返回名称为name的字段，用于证件类OCR的结构化结果
+func (rsp OCRRsp) Field(name string) (item OCRItem, ok bool) {
+	for _, item = range rsp.Items {
+		if item.Item == name {
+			return item, true
+		}
+	}
+	return OCRItem{}, false
+}
+
+//fillItems 将Items中的字段填入rsp中ocr tag为对应字段名称的OCRItem字段
+func fillItems(rsp interface{}, ocr OCRRsp) {
+	v := reflect.ValueOf(rsp).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("ocr")
+		if name == "" {
+			continue
+		}
+		if item, ok := ocr.Field(name); ok {
+			v.Field(i).Set(reflect.ValueOf(item))
+		}
+	}
+}
+
+//DriverLicenseOCRRsp 驾驶证OCR返回，各字段从Items中取得，没有识别出的字段为空
+type DriverLicenseOCRRsp struct {
+	OCRRsp
+	Number      OCRItem `json:"-" ocr:"证号"`   //证号
+	Name        OCRItem `json:"-" ocr:"姓名"`   //姓名
+	Sex         OCRItem `json:"-" ocr:"性别"`   //性别
+	Nationality OCRItem `json:"-" ocr:"国籍"`   //国籍
+	Address     OCRItem `json:"-" ocr:"住址"`   //住址
+	Birth       OCRItem `json:"-" ocr:"出生日期"` //出生日期
+	IssueDate   OCRItem `json:"-" ocr:"领证日期"` //初次领证日期
+	Class       OCRItem `json:"-" ocr:"准驾车型"` //准驾车型
+	ValidFrom   OCRItem `json:"-" ocr:"起始日期"` //有效期起始日期
+	ValidTo     OCRItem `json:"-" ocr:"有效日期"` //有效期截止日期
+}
+
+//VehicleLicenseOCRRsp 行驶证OCR返回，各字段从Items中取得，没有识别出的字段为空
+type VehicleLicenseOCRRsp struct {
+	OCRRsp
+	PlateNumber  OCRItem `json:"-" ocr:"车牌号码"` //车牌号码
+	VehicleType  OCRItem `json:"-" ocr:"车辆类型"` //车辆类型
+	Owner        OCRItem `json:"-" ocr:"所有人"`  //所有人
+	Address      OCRItem `json:"-" ocr:"住址"`   //住址
+	UseCharacter OCRItem `json:"-" ocr:"使用性质"` //使用性质
+	Model        OCRItem `json:"-" ocr:"品牌型号"` //品牌型号
+	VIN          OCRItem `json:"-" ocr:"识别代码"` //车辆识别代码
+	EngineNumber OCRItem `json:"-" ocr:"发动机号"` //发动机号码
+	RegisterDate OCRItem `json:"-" ocr:"注册日期"` //注册日期
+	IssueDate    OCRItem `json:"-" ocr:"发证日期"` //发证日期
+}
+
+//BankCardOCRRsp 银行卡OCR返回，各字段从Items中取得，没有识别出的字段为空
+type BankCardOCRRsp struct {
+	OCRRsp
+	CardNumber OCRItem `json:"-" ocr:"卡号"`   //卡号
+	CardType   OCRItem `json:"-" ocr:"卡类型"`  //卡类型，如借记卡
+	CardName   OCRItem `json:"-" ocr:"卡名字"`  //卡名字
+	Bank       OCRItem `json:"-" ocr:"银行信息"` //发卡银行
+	ValidDate  OCRItem `json:"-" ocr:"有效期"`  //有效期
+}
+
+//BizLicenseOCRRsp 营业执照OCR返回，各字段从Items中取得，没有识别出的字段为空
+type BizLicenseOCRRsp struct {
+	OCRRsp
+	RegNumber   OCRItem `json:"-" ocr:"注册号"`   //注册号
+	Name        OCRItem `json:"-" ocr:"公司名称"`  //公司名称
+	Address     OCRItem `json:"-" ocr:"地址"`    //地址
+	LegalPerson OCRItem `json:"-" ocr:"法定代表人"` //法定代表人
+	ValidPeriod OCRItem `json:"-" ocr:"营业期限"`  //营业期限
+}
+
+//PlateOCRRsp 车牌OCR返回
+type PlateOCRRsp struct {
+	OCRRsp
+	Plate OCRItem `json:"-" ocr:"车牌"` //车牌号码
+}
+
+type driverLicenseOCRReq struct {
+	AppID string      `json:"app_id"`          //App的 API ID
+	Image Image       `json:"image,omitempty"` //使用base64编码的二进制图片数据
+	URL   string      `json:"url,omitempty"`   //图片的url, image和url只需提供一个
+	Type  licenseType `json:"type"`            //0/1 行驶证/驾驶证
+}
+
+func (y *Youtu) driverLicenseOCR(t licenseType, image Image) (rsp OCRRsp, err error) {
+	image, scale, err := y.preprocessImage(image)
+	if err != nil {
+		return
+	}
+	data, url, err := image.fields()
+	if err != nil {
+		return
+	}
+	req := driverLicenseOCRReq{
+		AppID: y.appID(),
+		Image: data,
+		URL:   url,
+		Type:  t,
+	}
+	err = y.serviceRequest(serviceOCR, "driverlicenseocr", req, &rsp)
+	scaleItems(rsp.Items, scale)
+	return
+}
+
+//DriverLicenseOCR 识别驾驶证中的证号、姓名、准驾车型、有效期等字段
+func (y *Youtu) DriverLicenseOCR(image Image) (rsp DriverLicenseOCRRsp, err error) {
+	rsp.OCRRsp, err = y.driverLicenseOCR(licenseTypeDriver, image)
+	fillItems(&rsp, rsp.OCRRsp)
+	return
+}
+
+//VehicleLicenseOCR 识别行驶证中的车牌号码、车辆类型、所有人、品牌型号等字段
+func (y *Youtu) VehicleLicenseOCR(image Image) (rsp VehicleLicenseOCRRsp, err error) {
+	rsp.OCRRsp, err = y.driverLicenseOCR(licenseTypeVehicle, image)
+	fillItems(&rsp, rsp.OCRRsp)
+	return
+}
+
+//BankCardOCR 识别银行卡中的卡号、卡类型、银行信息、有效期等字段
+func (y *Youtu) BankCardOCR(image Image) (rsp BankCardOCRRsp, err error) {
+	rsp.OCRRsp, err = y.ocr("creditcardocr", image)
+	fillItems(&rsp, rsp.OCRRsp)
+	return
+}
+
+//BizLicenseOCR 识别营业执照中的注册号、公司名称、地址、法定代表人等字段
+func (y *Youtu) BizLicenseOCR(image Image) (rsp BizLicenseOCRRsp, err error) {
+	rsp.OCRRsp, err = y.ocr("bizlicenseocr", image)
+	fillItems(&rsp, rsp.OCRRsp)
+	return
+}
+
+//PlateOCR 识别图片中的车牌号码
+func (y *Youtu) PlateOCR(image Image) (rsp PlateOCRRsp, err error) {
+	rsp.OCRRsp, err = y.ocr("plateocr", image)
+	fillItems(&rsp, rsp.OCRRsp)
+	return
+}
@@ -0,0 +1,167 @@
+/*
+* File Name:	ocr_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-15
+ */
+
+package youtu
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestIDCardOCR(t *testing.T) {
+	rsp, err := yt.IDCardOCR(ImageFile(testDataDir+"imageA.jpg"), false)
+	if err != nil {
+		t.Errorf("IDCardOCR failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestNameCardOCR(t *testing.T) {
+	rsp, err := yt.NameCardOCR(ImageFile(testDataDir + "imageA.jpg"))
+	if err != nil {
+		t.Errorf("NameCardOCR failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestGeneralOCR(t *testing.T) {
+	rsp, err := yt.GeneralOCR(ImageFile(testDataDir + "imageA.jpg"))
+	if err != nil {
+		t.Errorf("GeneralOCR failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestDriverLicenseOCR(t *testing.T) {
+	rsp, err := yt.DriverLicenseOCR(ImageFile(testDataDir + "imageA.jpg"))
+	if err != nil {
+		t.Errorf("DriverLicenseOCR failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestVehicleLicenseOCR(t *testing.T) {
+	rsp, err := yt.VehicleLicenseOCR(ImageFile(testDataDir + "imageA.jpg"))
+	if err != nil {
+		t.Errorf("VehicleLicenseOCR failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestBankCardOCR(t *testing.T) {
+	rsp, err := yt.BankCardOCR(ImageFile(testDataDir + "imageA.jpg"))
+	if err != nil {
+		t.Errorf("BankCardOCR failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestBizLicenseOCR(t *testing.T) {
+	rsp, err := yt.BizLicenseOCR(ImageFile(testDataDir + "imageA.jpg"))
+	if err != nil {
+		t.Errorf("BizLicenseOCR failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestPlateOCR(t *testing.T) {
+	rsp, err := yt.PlateOCR(ImageFile(testDataDir + "imageA.jpg"))
+	if err != nil {
+		t.Errorf("PlateOCR failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestDriverLicenseOCRType(t *testing.T) {
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/youtu/ocrapi/driverlicenseocr" || req["type"] != 0.0 {
+			t.Errorf("path %s, req type %v\n", r.URL.Path, req["type"])
+		}
+		w.Write([]byte(`{"items":[{"item":"车牌号码","itemstring":"粤B12345","itemconf":0.99}]}`))
+	})
+	defer ts.Close()
+
+	rsp, err := y.VehicleLicenseOCR(ImageFile(testDataDir + "imageA.jpg"))
+	if err != nil {
+		t.Errorf("VehicleLicenseOCR failed: %s\n", err)
+		return
+	}
+	if item, ok := rsp.Field("车牌号码"); !ok || item.ItemString != "粤B12345" {
+		t.Errorf("Field() = %#v, %v\n", item, ok)
+	}
+	if _, ok := rsp.Field("所有人"); ok {
+		t.Errorf("Field() found missing item\n")
+	}
+	if rsp.PlateNumber.ItemString != "粤B12345" || rsp.PlateNumber.ItemConf != 0.99 || rsp.Owner.Item != "" {
+		t.Errorf("PlateNumber = %#v, Owner = %#v\n", rsp.PlateNumber, rsp.Owner)
+	}
+}
+
+func TestOCRFields(t *testing.T) {
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/youtu/ocrapi/driverlicenseocr":
+			w.Write([]byte(`{"items":[{"item":"证号","itemstring":"440300199001010000"},{"item":"准驾车型","itemstring":"C1"}]}`))
+		case "/youtu/ocrapi/creditcardocr":
+			w.Write([]byte(`{"items":[{"item":"卡号","itemstring":"6222 0000 0000 0000"},{"item":"银行信息","itemstring":"工商银行"}]}`))
+		case "/youtu/ocrapi/bizlicenseocr":
+			w.Write([]byte(`{"items":[{"item":"注册号","itemstring":"110000000000000"},{"item":"法定代表人","itemstring":"张三"}]}`))
+		case "/youtu/ocrapi/plateocr":
+			w.Write([]byte(`{"items":[{"item":"车牌","itemstring":"京A12345","itemcoord":{"x":1,"y":2,"width":3,"height":4}}]}`))
+		}
+	})
+	defer ts.Close()
+	image := ImageFile(testDataDir + "imageA.jpg")
+
+	dl, err := y.DriverLicenseOCR(image)
+	if err != nil || dl.Number.ItemString != "440300199001010000" || dl.Class.ItemString != "C1" || dl.Name.ItemString != "" {
+		t.Errorf("DriverLicenseOCR = %#v, %v\n", dl, err)
+	}
+	bc, err := y.BankCardOCR(image)
+	if err != nil || bc.CardNumber.ItemString != "6222 0000 0000 0000" || bc.Bank.ItemString != "工商银行" {
+		t.Errorf("BankCardOCR = %#v, %v\n", bc, err)
+	}
+	bl, err := y.BizLicenseOCR(image)
+	if err != nil || bl.RegNumber.ItemString != "110000000000000" || bl.LegalPerson.ItemString != "张三" {
+		t.Errorf("BizLicenseOCR = %#v, %v\n", bl, err)
+	}
+	plate, err := y.PlateOCR(image)
+	if err != nil || plate.Plate.ItemString != "京A12345" || plate.Plate.ItemCoord != (ItemCoord{1, 2, 3, 4}) || len(plate.Items) != 1 {
+		t.Errorf("PlateOCR = %#v, %v\n", plate, err)
+	}
+}
+
+func TestOCRService(t *testing.T) {
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/youtu/ocrapi/generalocr" {
+			t.Errorf("path = %s\n", r.URL.Path)
+		}
+		w.Write([]byte(`{"items":[{"itemstring":"youtu","itemcoord":{"x":10,"y":20,"width":30,"height":40},"words":[{"character":"y","confidence":0.9}]}]}`))
+	})
+	defer ts.Close()
+	y.SetPreprocess(&Preprocess{MaxSide: 100})
+
+	rsp, err := y.GeneralOCR(ImageData(encodeTestImage(t, FormatPNG, 200, 100)))
+	if err != nil {
+		t.Errorf("GeneralOCR failed: %s\n", err)
+		return
+	}
+	if len(rsp.Items) != 1 || rsp.Items[0].ItemCoord != (ItemCoord{20, 40, 60, 80}) || len(rsp.Items[0].Words) != 1 {
+		t.Errorf("rsp: %#v\n", rsp)
+	}
+}
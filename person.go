@@ -0,0 +1,88 @@
+/*
+* File Name:	person.go
+* Description:  个体信息和备注元数据
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-28
+ */
+
+package youtu
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+const (
+	//TagMaxSize 个体备注信息(tag)的最大字节数
+	TagMaxSize = 1024
+)
+
+var (
+	//ErrTagTooLarge 备注信息超过TagMaxSize
+	ErrTagTooLarge = errors.New("tag too large")
+)
+
+//checkTag 检查备注信息的长度
+func checkTag(tag string) error {
+	if len(tag) > TagMaxSize {
+		return ErrTagTooLarge
+	}
+	return nil
+}
+
+//Person 个体信息
+type Person struct {
+	ID       string   //个体id
+	Name     string   //名字
+	Tag      string   //备注信息
+	GroupIDs []string //所属的组
+	FaceIDs  []string //包含的人脸
+}
+
+//Metadata 将JSON格式的备注信息解析到v中，备注信息为空时v不变
+func (p Person) Metadata(v interface{}) error {
+	if p.Tag == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(p.Tag), v)
+}
+
+//SetMetadata 将v编码为JSON保存在备注信息中，超过TagMaxSize时返回ErrTagTooLarge
+func (p *Person) SetMetadata(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tag := string(data)
+	if err = checkTag(tag); err != nil {
+		return err
+	}
+	p.Tag = tag
+	return nil
+}
+
+//GetPerson 获取个体的名字、备注信息、所属的组以及人脸
+func (y *Youtu) GetPerson(personID string) (p Person, err error) {
+	rsp, err := y.GetInfo(personID)
+	if err != nil {
+		return
+	}
+	p = Person{
+		ID:       rsp.PersonID,
+		Name:     rsp.PersonName,
+		Tag:      rsp.Tag,
+		GroupIDs: rsp.GroupIDs,
+		FaceIDs:  rsp.FaceIDs,
+	}
+	if p.ID == "" {
+		p.ID = personID
+	}
+	return
+}
+
+//SetPerson 更新个体的名字和备注信息。
+//所属的组和人脸不能通过SetInfo修改，请使用SetPersonGroups、AddFace和DelFace
+func (y *Youtu) SetPerson(p Person) error {
+	_, err := y.SetInfo(p.ID, p.Name, p.Tag)
+	return err
+}
@@ -0,0 +1,67 @@
+/*
+* File Name:	person_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-28
+ */
+
+package youtu
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+type employee struct {
+	EmployeeID string `json:"employee_id"`
+	Department string `json:"department"`
+}
+
+func TestPersonMetadata(t *testing.T) {
+	y, _, done := newFakeYoutu()
+	defer done()
+	img := ImageFile(testDataDir + "imageA.jpg")
+	y.NewPerson("alice", "Alice", []string{"dev"}, img, "")
+
+	p, err := y.GetPerson("alice")
+	if err != nil {
+		t.Fatalf("GetPerson failed: %s\n", err)
+	}
+	if err = p.SetMetadata(employee{"E001", "R&D"}); err != nil {
+		t.Fatalf("SetMetadata failed: %s\n", err)
+	}
+	p.Name = "Alice Wang"
+	if err = y.SetPerson(p); err != nil {
+		t.Fatalf("SetPerson failed: %s\n", err)
+	}
+
+	//移动组后名字和备注信息保持不变
+	faces := newFaceImageDir(t, p.FaceIDs...)
+	defer os.RemoveAll(string(faces))
+	if err = y.SetPersonGroups("alice", []string{"ops"}, faces); err != nil {
+		t.Fatalf("SetPersonGroups failed: %s\n", err)
+	}
+	got, err := y.GetPerson("alice")
+	if err != nil {
+		t.Fatalf("GetPerson failed: %s\n", err)
+	}
+	var e employee
+	if err = got.Metadata(&e); err != nil {
+		t.Errorf("Metadata failed: %s\n", err)
+	}
+	if got.Name != "Alice Wang" || e.EmployeeID != "E001" || e.Department != "R&D" || !sameStrings(got.GroupIDs, []string{"ops"}) {
+		t.Errorf("person: %#v, metadata: %#v\n", got, e)
+	}
+}
+
+func TestPersonTagTooLarge(t *testing.T) {
+	var p Person
+	if err := p.SetMetadata(strings.Repeat("x", TagMaxSize)); err != ErrTagTooLarge {
+		t.Errorf("SetMetadata err = %v, want ErrTagTooLarge\n", err)
+	}
+	y := Init(as, DefaultHost)
+	if _, err := y.SetInfo("alice", "", strings.Repeat("x", TagMaxSize+1)); err != ErrTagTooLarge {
+		t.Errorf("SetInfo err = %v, want ErrTagTooLarge\n", err)
+	}
+}
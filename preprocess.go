@@ -0,0 +1,186 @@
+/*
+* File Name:	preprocess.go
+* Description:  上传前的图片缩放和重新编码
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-10
+ */
+
+package youtu
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io/ioutil"
+	"math"
+)
+
+//Preprocess 上传前的图片预处理选项
+type Preprocess struct {
+	MaxSide int //缩放后的最大边长(像素)，保持宽高比，0表示不缩放
+	Quality int //重新编码为JPEG的质量[1,100]，0表示使用jpeg.DefaultQuality
+	//AutoOrient 按照JPEG的EXIF方向旋转像素并去除EXIF信息(包括GPS)，
+	//返回结果中的坐标对应图片显示时的方向
+	AutoOrient bool
+}
+
+//SetPreprocess 设置上传前的图片预处理，nil表示不处理(默认)。
+//返回结果中的坐标会被换算回原图的像素坐标
+func (y *Youtu) SetPreprocess(p *Preprocess) {
+	y.preprocess = p
+}
+
+//preprocessImage 按照y.preprocess处理图片，返回处理后的图片以及原图相对于处理后图片的缩放比例
+func (y *Youtu) preprocessImage(img Image) (Image, float64, error) {
+	p := y.preprocess
+	if p == nil || img.isZero() || img.IsURL() {
+		return img, 1, nil
+	}
+	return p.apply(img)
+}
+
+func (p *Preprocess) apply(img Image) (Image, float64, error) {
+	r, err := img.open()
+	if err != nil {
+		return img, 1, err
+	}
+	data, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return img, 1, err
+	}
+	orig := ImageData(data)
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		//BMP等无法解码的图片原样上传，由validate校验
+		return orig, 1, nil
+	}
+	reencode := false
+	if p.AutoOrient {
+		orientation, hasExif := exifOrientation(data)
+		if orientation > 1 {
+			src = orient(src, orientation)
+		}
+		//重新编码时不会写入EXIF信息
+		reencode = hasExif
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	scale := 1.0
+	if p.MaxSide > 0 && (w > p.MaxSide || h > p.MaxSide) {
+		scale = float64(maxInt(w, h)) / float64(p.MaxSide)
+		w = maxInt(1, int(math.Round(float64(w)/scale)))
+		h = maxInt(1, int(math.Round(float64(h)/scale)))
+		src = resize(src, w, h)
+		reencode = true
+	}
+	if !reencode && len(data) <= ImageMaxSize {
+		//不需要处理且大小符合要求，避免重新编码损失质量
+		return orig, 1, nil
+	}
+	quality := p.Quality
+	if quality <= 0 {
+		quality = jpeg.DefaultQuality
+	}
+	var buf bytes.Buffer
+	if err = jpeg.Encode(&buf, src, &jpeg.Options{Quality: quality}); err != nil {
+		return orig, 1, err
+	}
+	return ImageData(buf.Bytes()), scale, nil
+}
+
+//resize 使用区域平均缩小图片到w*h
+func resize(src image.Image, w, h int) *image.RGBA {
+	rgba := toRGBA(src)
+	sw, sh := rgba.Bounds().Dx(), rgba.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, (y+1)*sh/h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, (x+1)*sw/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				off := rgba.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					sum[0] += int(rgba.Pix[off])
+					sum[1] += int(rgba.Pix[off+1])
+					sum[2] += int(rgba.Pix[off+2])
+					sum[3] += int(rgba.Pix[off+3])
+					off += 4
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			d := dst.PixOffset(x, y)
+			for i := 0; i < 4; i++ {
+				dst.Pix[d+i] = uint8(sum[i] / n)
+			}
+		}
+	}
+	return dst
+}
+
+//toRGBA 转换为原点为(0, 0)的RGBA图片
+func toRGBA(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	if rgba, ok := src.(*image.RGBA); ok && b.Min == (image.Point{}) {
+		return rgba
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+	return rgba
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+//scale 将人脸框换算回原图坐标
+func (f *Face) scale(s float64) {
+	if s == 1 {
+		return
+	}
+	f.X = int32(math.Round(float64(f.X) * s))
+	f.Y = int32(math.Round(float64(f.Y) * s))
+	f.Width = float32(float64(f.Width) * s)
+	f.Height = float32(float64(f.Height) * s)
+}
+
+//scale 将返回结果中的坐标换算回原图坐标
+func (rsp *DetectFaceRsp) scale(s float64) {
+	if s == 1 {
+		return
+	}
+	rsp.ImageWidth = int32(math.Round(float64(rsp.ImageWidth) * s))
+	rsp.ImageHeight = int32(math.Round(float64(rsp.ImageHeight) * s))
+	for i := range rsp.Face {
+		rsp.Face[i].scale(s)
+	}
+}
+
+//scale 将返回结果中的轮廓点换算回原图坐标
+func (rsp *FaceShapeRsp) scale(s float64) {
+	if s == 1 {
+		return
+	}
+	rsp.ImageWidth = int(math.Round(float64(rsp.ImageWidth) * s))
+	rsp.ImageHeight = int(math.Round(float64(rsp.ImageHeight) * s))
+	for i := range rsp.FaceShape {
+		fs := &rsp.FaceShape[i]
+		for _, ps := range [][]pos{fs.FaceProfile, fs.LeftEye, fs.RightEye, fs.LeftEyebrow, fs.RightEyebrow, fs.Mouth, fs.Nose} {
+			for j := range ps {
+				ps[j].X = int(math.Round(float64(ps[j].X) * s))
+				ps[j].Y = int(math.Round(float64(ps[j].Y) * s))
+			}
+		}
+	}
+}
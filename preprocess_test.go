@@ -0,0 +1,75 @@
+/*
+* File Name:	preprocess_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-10
+ */
+
+package youtu
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"net/http"
+	"testing"
+)
+
+func TestPreprocessDetectFace(t *testing.T) {
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Image string `json:"image"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Decode request failed: %s\n", err)
+		}
+		data, _ := base64.StdEncoding.DecodeString(req.Image)
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil || format != "jpeg" || cfg.Width != 1000 || cfg.Height != 500 {
+			t.Errorf("uploaded %s %dx%d, err %v\n", format, cfg.Width, cfg.Height, err)
+		}
+		w.Write([]byte(`{"image_width":1000,"image_height":500,"face":[{"x":100,"y":50,"width":40,"height":60}]}`))
+	})
+	defer ts.Close()
+	y.SetPreprocess(&Preprocess{MaxSide: 1000, Quality: 80})
+
+	rsp, err := y.DetectFace(ImageData(encodeTestImage(t, FormatPNG, 3000, 1500)), false)
+	if err != nil {
+		t.Errorf("DetectFace failed: %s\n", err)
+		return
+	}
+	f := rsp.Face[0]
+	if rsp.ImageWidth != 3000 || rsp.ImageHeight != 1500 || f.X != 300 || f.Y != 150 || f.Width != 120 || f.Height != 180 {
+		t.Errorf("rsp not rescaled: %#v\n", rsp)
+	}
+}
+
+func TestResize(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for i := range src.Pix {
+		src.Pix[i] = 200
+	}
+	for x := 0; x < 2; x++ {
+		for y := 0; y < 2; y++ {
+			off := src.PixOffset(x, y)
+			src.Pix[off], src.Pix[off+1], src.Pix[off+2] = 0, 0, 0
+		}
+	}
+	dst := resize(src, 2, 1)
+	if dst.Bounds().Dx() != 2 || dst.Bounds().Dy() != 1 {
+		t.Errorf("resize bounds = %v\n", dst.Bounds())
+	}
+	if dst.Pix[0] != 0 || dst.Pix[4] != 200 {
+		t.Errorf("resize pixels = %v\n", dst.Pix)
+	}
+}
+
+func TestPreprocessKeepSmallImage(t *testing.T) {
+	data := encodeTestImage(t, FormatPNG, 100, 100)
+	p := &Preprocess{MaxSide: 1000}
+	img, scale, err := p.apply(ImageData(data))
+	if err != nil || scale != 1 || !bytes.Equal(img.data, data) {
+		t.Errorf("apply() = %d bytes, scale %v, err %v\n", len(img.data), scale, err)
+	}
+}
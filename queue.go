@@ -0,0 +1,402 @@
+/*
+* File Name:	queue.go
+* Description:  持久化的人脸库修改队列
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-08
+ */
+
+package youtu
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	//DefaultRetryInterval 队列第一次重试的等待时间
+	DefaultRetryInterval = time.Second
+	//DefaultMaxRetryInterval 队列重试的最长等待时间
+	DefaultMaxRetryInterval = time.Minute
+	//DefaultQueueCompactSize 日志自上次压缩后增长超过该字节数时压缩
+	DefaultQueueCompactSize = 16 << 20
+	//DefaultQueueKeepKeys 压缩后保留的已完成请求key的数量
+	DefaultQueueKeepKeys = 10000
+	//queueCompactDone 自上次压缩后完成的请求数达到该数量时压缩
+	queueCompactDone = 1000
+)
+
+var (
+	//ErrQueueClosed 队列已经关闭
+	ErrQueueClosed = errors.New("queue closed")
+)
+
+//QueueOptions 队列选项
+type QueueOptions struct {
+	RetryInterval    time.Duration     //网络错误后第一次重试的等待时间，之后每次加倍，默认DefaultRetryInterval
+	MaxRetryInterval time.Duration     //重试的最长等待时间，默认DefaultMaxRetryInterval
+	OnDone           func(QueueResult) //请求完成(成功或接口返回错误)后、记录完成之前调用，同一请求可能被调用多次
+	CompactSize      int64             //日志增长超过该字节数时压缩，默认DefaultQueueCompactSize
+	KeepKeys         int               //用于去重的已完成请求key的数量，默认DefaultQueueKeepKeys
+}
+
+//QueueResult 队列中一个请求的结果
+type QueueResult struct {
+	Key      string          //幂等key
+	Endpoint string          //接口，"服务/接口名"
+	Body     json.RawMessage //接口返回的JSON，可以解析为对应的Rsp，如NewPersonRsp
+	Err      error           //接口返回的错误，成功时为nil
+}
+
+//queueRecord 日志中的一条记录，op为enqueue或done
+type queueRecord struct {
+	Op       string          `json:"op"`
+	Key      string          `json:"key"`
+	Endpoint string          `json:"endpoint,omitempty"`
+	Body     json.RawMessage `json:"body,omitempty"` //请求体，图片已经以base64编码
+}
+
+//Queue 以只追加的日志文件保存的人脸库修改队列。
+//请求在加入时编码并写入日志，Run按加入顺序发送，网络错误时等待后重试同一请求，
+//接口返回错误时不再重试。进程重启后OpenQueue从日志恢复没有完成的请求。
+//日志增长到一定大小后被重写，只保留没有完成的请求和最近KeepKeys个已完成请求的key。
+//网络错误可能发生在接口已经执行之后，重试时可能得到个体已存在等错误，需要在OnDone中处理。
+//OnDone在完成记录写入之前调用，每个请求的结果至少通知一次，进程退出时可能重复通知
+type Queue struct {
+	y         *Youtu
+	opt       QueueOptions
+	mu        sync.Mutex
+	path      string
+	f         *os.File
+	size      int64           //日志的字节数
+	compacted int64           //上次压缩后日志的字节数
+	doneCount int             //上次压缩后完成的请求数
+	keys      map[string]bool //没有完成和最近完成的请求的key，用于去重
+	done      []string        //最近完成的请求的key，按完成顺序
+	pending   []queueRecord   //没有完成的请求
+	notify    chan struct{}
+}
+
+//OpenQueue 打开或创建日志文件path，恢复其中没有完成的请求
+func OpenQueue(y *Youtu, path string, opt QueueOptions) (*Queue, error) {
+	if opt.RetryInterval <= 0 {
+		opt.RetryInterval = DefaultRetryInterval
+	}
+	if opt.MaxRetryInterval <= 0 {
+		opt.MaxRetryInterval = DefaultMaxRetryInterval
+	}
+	if opt.CompactSize <= 0 {
+		opt.CompactSize = DefaultQueueCompactSize
+	}
+	if opt.KeepKeys <= 0 {
+		opt.KeepKeys = DefaultQueueKeepKeys
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	q := &Queue{
+		y:      y,
+		opt:    opt,
+		path:   path,
+		f:      f,
+		keys:   make(map[string]bool),
+		notify: make(chan struct{}, 1),
+	}
+	if err = q.load(); err == nil {
+		err = q.maybeCompact()
+	}
+	if err != nil {
+		q.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+//load 读取日志，最后一行不完整(写入时中断)时忽略并截断
+func (q *Queue) load() error {
+	r := bufio.NewReader(q.f)
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			//没有换行的最后一行是不完整的记录
+			q.size = offset
+			return q.f.Truncate(offset)
+		}
+		var rec queueRecord
+		if err = json.Unmarshal(line, &rec); err != nil {
+			return err
+		}
+		offset += int64(len(line))
+		switch rec.Op {
+		case "enqueue":
+			q.keys[rec.Key] = true
+			q.pending = append(q.pending, rec)
+		case "done":
+			q.markDone(rec.Key)
+		}
+	}
+}
+
+//markDone 将请求从pending移到done，只保留最近KeepKeys个已完成请求的key
+func (q *Queue) markDone(key string) {
+	q.remove(key)
+	q.keys[key] = true
+	q.done = append(q.done, key)
+	q.doneCount++
+	if n := len(q.done) - q.opt.KeepKeys; n > 0 {
+		for _, k := range q.done[:n] {
+			delete(q.keys, k)
+		}
+		q.done = append([]string(nil), q.done[n:]...)
+	}
+}
+
+func (q *Queue) remove(key string) {
+	for i, rec := range q.pending {
+		if rec.Key == key {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+//append 将记录写入日志并同步到磁盘
+func (q *Queue) append(rec queueRecord) error {
+	if q.f == nil {
+		return ErrQueueClosed
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	n, err := q.f.Write(append(data, '\n'))
+	q.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return q.f.Sync()
+}
+
+//maybeCompact 日志增长超过CompactSize或完成了足够多的请求后压缩
+func (q *Queue) maybeCompact() error {
+	if q.size-q.compacted < q.opt.CompactSize && q.doneCount < queueCompactDone {
+		return nil
+	}
+	return q.compact()
+}
+
+//compact 重写日志，只保留最近完成的请求的key和没有完成的请求，已完成请求的请求体被丢弃
+func (q *Queue) compact() error {
+	if q.f == nil {
+		return ErrQueueClosed
+	}
+	tmp := q.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	var size int64
+	write := func(rec queueRecord) {
+		data, e := json.Marshal(rec)
+		if err == nil {
+			err = e
+		}
+		n, _ := w.Write(append(data, '\n'))
+		size += int64(n)
+	}
+	for _, key := range q.done {
+		write(queueRecord{Op: "done", Key: key})
+	}
+	for _, rec := range q.pending {
+		write(rec)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, q.path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	q.f.Close()
+	if q.f, err = os.OpenFile(q.path, os.O_RDWR|os.O_APPEND, 0644); err != nil {
+		q.f = nil
+		return err
+	}
+	q.size, q.compacted, q.doneCount = size, size, 0
+	return nil
+}
+
+//Enqueue 将endpoint的请求req加入队列，endpoint格式与Call相同。
+//key已经在队列中或者在最近KeepKeys个完成的请求中时不加入，added为false
+func (q *Queue) Enqueue(key, endpoint string, req interface{}) (added bool, err error) {
+	if err = validateRequest(req); err != nil {
+		return
+	}
+	var body bytes.Buffer
+	if err = encodeJSON(&body, req); err != nil {
+		return
+	}
+	service, ifname := splitEndpoint(endpoint)
+	rec := queueRecord{Op: "enqueue", Key: key, Endpoint: service + "/" + ifname, Body: body.Bytes()}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.keys[key] {
+		return false, nil
+	}
+	if err = q.append(rec); err != nil {
+		return
+	}
+	q.keys[key] = true
+	q.pending = append(q.pending, rec)
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return true, nil
+}
+
+//NewPerson 将NewPerson加入队列，结果为NewPersonRsp
+func (q *Queue) NewPerson(key, personID, personName string, groupIDs []string, image Image, tag string) (bool, error) {
+	req, err := q.y.newPersonReq(personID, personName, groupIDs, image, tag)
+	if err != nil {
+		return false, err
+	}
+	return q.Enqueue(key, "newperson", req)
+}
+
+//AddFace 将AddFace加入队列，结果为AddFaceRsp
+func (q *Queue) AddFace(key, personID string, images []Image, tag string) (bool, error) {
+	req, err := q.y.addFaceReq(personID, images, tag)
+	if err != nil {
+		return false, err
+	}
+	return q.Enqueue(key, "addface", req)
+}
+
+//DelPerson 将DelPerson加入队列，结果为DelPersonRsp
+func (q *Queue) DelPerson(key, personID string) (bool, error) {
+	return q.Enqueue(key, "delperson", delPersonReq{AppID: q.y.appID(), PersonID: personID})
+}
+
+//DelFace 将DelFace加入队列，结果为DelFaceRsp
+func (q *Queue) DelFace(key, personID string, faceIDs []string) (bool, error) {
+	return q.Enqueue(key, "delface", delFaceReq{AppID: q.y.appID(), PersonID: personID, FaceIDs: faceIDs})
+}
+
+//SetInfo 将SetInfo加入队列，结果为SetInfoRsp
+func (q *Queue) SetInfo(key, personID, personName, tag string) (bool, error) {
+	if err := checkTag(tag); err != nil {
+		return false, err
+	}
+	return q.Enqueue(key, "setinfo", setInfoReq{AppID: q.y.appID(), PersonID: personID, PersonName: personName, Tag: tag})
+}
+
+//Len 没有完成的请求数
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+//head 返回第一个没有完成的请求
+func (q *Queue) head() (rec queueRecord, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return
+	}
+	return q.pending[0], true
+}
+
+//Run 按顺序发送队列中的请求，直到ctx取消。队列为空时等待新的请求
+func (q *Queue) Run(ctx context.Context) error {
+	wait := q.opt.RetryInterval
+	for {
+		rec, ok := q.head()
+		if !ok {
+			select {
+			case <-q.notify:
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		result, err := q.send(ctx, rec)
+		if err != nil {
+			//网络错误，等待后重试同一个请求
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			if wait *= 2; wait > q.opt.MaxRetryInterval {
+				wait = q.opt.MaxRetryInterval
+			}
+			continue
+		}
+		wait = q.opt.RetryInterval
+		//先通知结果再记录完成，进程在两者之间退出时重启后重新发送请求并再次调用OnDone
+		if q.opt.OnDone != nil {
+			q.opt.OnDone(result)
+		}
+		q.mu.Lock()
+		err = q.append(queueRecord{Op: "done", Key: rec.Key})
+		if err == nil {
+			q.markDone(rec.Key)
+			err = q.maybeCompact()
+		}
+		q.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+//send 发送请求，只有网络错误或者无法解析返回时返回err，接口返回的错误记录在result中
+func (q *Queue) send(ctx context.Context, rec queueRecord) (result QueueResult, err error) {
+	service, ifname := splitEndpoint(rec.Endpoint)
+	if err = q.y.limiter.wait(ctx); err != nil {
+		return
+	}
+	body, err := q.y.get(ctx, q.y.interfaceURL(service, ifname), bytes.NewReader(rec.Body))
+	if err != nil {
+		return
+	}
+	var status Status
+	if err = json.Unmarshal(body, &status); err != nil {
+		return
+	}
+	if status.OK() {
+		q.y.cache.update(service, ifname)
+	}
+	return QueueResult{Key: rec.Key, Endpoint: rec.Endpoint, Body: body, Err: status.Err()}, nil
+}
+
+//Close 关闭日志文件，没有完成的请求在下次OpenQueue时恢复
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.f == nil {
+		return ErrQueueClosed
+	}
+	err := q.f.Close()
+	q.f = nil
+	return err
+}
@@ -0,0 +1,269 @@
+/*
+* File Name:	queue_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-10-08
+ */
+
+package youtu
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	g := newFakeGallery()
+	//前3个请求返回不能解析的内容，模拟网络不可用
+	var failures int32 = 3
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("service unavailable"))
+			return
+		}
+		g.ServeHTTP(w, r)
+	})
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "youtu")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue.log")
+
+	q, err := OpenQueue(y, path, QueueOptions{})
+	if err != nil {
+		t.Fatalf("OpenQueue failed: %s", err)
+	}
+	image := ImageFile(testDataDir + "imageA.jpg")
+	if added, err := q.NewPerson("k1", "p1", "Alice", []string{"g1"}, image, "tag"); !added || err != nil {
+		t.Fatalf("NewPerson = %v, %v", added, err)
+	}
+	if added, err := q.AddFace("k2", "p1", []Image{ImageFile(testDataDir + "imageB.jpg")}, ""); !added || err != nil {
+		t.Fatalf("AddFace = %v, %v", added, err)
+	}
+	if added, err := q.DelPerson("k3", "p2"); !added || err != nil {
+		t.Fatalf("DelPerson = %v, %v", added, err)
+	}
+	if added, err := q.SetInfo("k1", "p1", "Bob", ""); added || err != nil {
+		t.Errorf("duplicate SetInfo = %v, %v, want false", added, err)
+	}
+	if _, err := q.SetInfo("k4", "p1", "", string(make([]byte, TagMaxSize+1))); err != ErrTagTooLarge {
+		t.Errorf("SetInfo err = %v, want ErrTagTooLarge", err)
+	}
+	if err = q.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+	if _, err = q.DelPerson("k5", "p1"); err != ErrQueueClosed {
+		t.Errorf("Enqueue after Close err = %v, want ErrQueueClosed", err)
+	}
+
+	//重新打开后恢复没有完成的请求
+	results := make(chan QueueResult, 10)
+	opt := QueueOptions{
+		RetryInterval:    10 * time.Millisecond,
+		MaxRetryInterval: 20 * time.Millisecond,
+		OnDone:           func(r QueueResult) { results <- r },
+	}
+	q, err = OpenQueue(y, path, opt)
+	if err != nil {
+		t.Fatalf("OpenQueue failed: %s", err)
+	}
+	if q.Len() != 3 {
+		t.Errorf("Len = %d, want 3", q.Len())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- q.Run(ctx) }()
+
+	var got []QueueResult
+	for len(got) < 3 {
+		select {
+		case r := <-results:
+			got = append(got, r)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout, got %d results", len(got))
+		}
+	}
+	for i, key := range []string{"k1", "k2", "k3"} {
+		if got[i].Key != key {
+			t.Errorf("result %d key = %s, want %s", i, got[i].Key, key)
+		}
+	}
+	if got[0].Err != nil || got[0].Endpoint != "api/newperson" {
+		t.Errorf("result 0 = %s %v", got[0].Endpoint, got[0].Err)
+	}
+	var rsp NewPersonRsp
+	if err = json.Unmarshal(got[0].Body, &rsp); err != nil || rsp.FaceID == "" {
+		t.Errorf("NewPersonRsp = %+v, %v", rsp, err)
+	}
+	if e, ok := got[2].Err.(*APIError); !ok || e.Code != -1001 {
+		t.Errorf("result 2 err = %v, want -1001", got[2].Err)
+	}
+	if p := g.persons["p1"]; p == nil || len(p.faces) != 2 || p.tag != "tag" {
+		t.Errorf("person p1 = %+v", p)
+	}
+
+	//队列为空时Run等待新的请求
+	if added, err := q.SetInfo("k4", "p1", "Bob", ""); !added || err != nil {
+		t.Fatalf("SetInfo = %v, %v", added, err)
+	}
+	select {
+	case r := <-results:
+		if r.Key != "k4" || r.Err != nil {
+			t.Errorf("result = %s %v", r.Key, r.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for k4")
+	}
+	if g.persons["p1"].name != "Bob" {
+		t.Errorf("person_name = %s, want Bob", g.persons["p1"].name)
+	}
+	cancel()
+	if err = <-done; err != context.Canceled {
+		t.Errorf("Run err = %v, want context.Canceled", err)
+	}
+	q.Close()
+
+	//已完成的key在重新打开后仍然去重，不完整的最后一行被忽略
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %s", err)
+	}
+	f.Write([]byte(`{"op":"enqueue","key":"k6"`))
+	f.Close()
+	q, err = OpenQueue(y, path, opt)
+	if err != nil {
+		t.Fatalf("OpenQueue failed: %s", err)
+	}
+	defer q.Close()
+	if q.Len() != 0 {
+		t.Errorf("Len = %d, want 0", q.Len())
+	}
+	if added, err := q.DelPerson("k1", "p1"); added || err != nil {
+		t.Errorf("DelPerson = %v, %v, want false", added, err)
+	}
+	if added, err := q.DelPerson("k6", "p1"); !added || err != nil {
+		t.Errorf("DelPerson = %v, %v, want true", added, err)
+	}
+}
+
+func TestQueueCompact(t *testing.T) {
+	y, g, done := newFakeYoutu()
+	defer done()
+	dir, err := ioutil.TempDir("", "youtu")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue.log")
+
+	results := make(chan QueueResult, 10)
+	opt := QueueOptions{CompactSize: 1, KeepKeys: 2, OnDone: func(r QueueResult) { results <- r }}
+	q, err := OpenQueue(y, path, opt)
+	if err != nil {
+		t.Fatalf("OpenQueue failed: %s", err)
+	}
+	image := ImageFile(testDataDir + "imageA.jpg")
+	q.NewPerson("k1", "p1", "Alice", []string{"g1"}, image, "")
+	q.AddFace("k2", "p1", []Image{image}, "")
+	q.AddFace("k3", "p1", []Image{image}, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() { stopped <- q.Run(ctx) }()
+	for i := 0; i < 3; i++ {
+		select {
+		case <-results:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for result %d", i)
+		}
+	}
+	cancel()
+	<-stopped
+	q.Close()
+	if n := len(g.persons["p1"].faces); n != 3 {
+		t.Errorf("p1 has %d faces, want 3", n)
+	}
+
+	//压缩后的日志只有最近完成的key，没有请求体
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %s", err)
+	}
+	want := `{"op":"done","key":"k2"}` + "\n" + `{"op":"done","key":"k3"}` + "\n"
+	if string(data) != want {
+		t.Errorf("journal = %q, want %q", data, want)
+	}
+
+	q, err = OpenQueue(y, path, opt)
+	if err != nil {
+		t.Fatalf("OpenQueue failed: %s", err)
+	}
+	defer q.Close()
+	if added, _ := q.DelPerson("k3", "p1"); added {
+		t.Errorf("recent key k3 added again")
+	}
+	if added, _ := q.DelPerson("k1", "p1"); !added {
+		t.Errorf("key k1 beyond KeepKeys not added")
+	}
+	if q.Len() != 1 {
+		t.Errorf("Len = %d, want 1", q.Len())
+	}
+}
+
+func TestQueueRedeliver(t *testing.T) {
+	y, _, done := newFakeYoutu()
+	defer done()
+	dir, err := ioutil.TempDir("", "youtu")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue.log")
+
+	//OnDone中退出(以panic模拟)时完成没有被记录
+	opt := QueueOptions{OnDone: func(r QueueResult) { panic("crash") }}
+	q, err := OpenQueue(y, path, opt)
+	if err != nil {
+		t.Fatalf("OpenQueue failed: %s", err)
+	}
+	q.NewPerson("k1", "p1", "Alice", []string{"g1"}, ImageFile(testDataDir+"imageA.jpg"), "")
+	func() {
+		defer func() { recover() }()
+		q.Run(context.Background())
+	}()
+	q.Close()
+
+	//重新打开后再次发送并通知结果
+	results := make(chan QueueResult, 1)
+	opt.OnDone = func(r QueueResult) { results <- r }
+	q, err = OpenQueue(y, path, opt)
+	if err != nil {
+		t.Fatalf("OpenQueue failed: %s", err)
+	}
+	defer q.Close()
+	if q.Len() != 1 {
+		t.Errorf("Len = %d, want 1", q.Len())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+	select {
+	case r := <-results:
+		if r.Key != "k1" {
+			t.Errorf("result key = %s, want k1", r.Key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for k1")
+	}
+}
@@ -34,7 +34,7 @@ func main() {
 	}
 
 	yt := youtu.Init(as, youtu.DefaultHost)
-	df, err := yt.DetectFace(imgData, false)
+	df, err := yt.DetectFace(youtu.ImageData(imgData), false)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "DetectFace() failed: %s", err)
 		return
@@ -0,0 +1,70 @@
+/*
+* File Name:	status.go
+* Description:  接口返回的公共字段
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-29
+ */
+
+package youtu
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+//Status 所有接口返回的公共字段，嵌入在各个Rsp中
+type Status struct {
+	SessionID string          `json:"session_id"` //相应请求的session标识符
+	ErrorCode int             `json:"errorcode"`  //返回状态码，0表示成功
+	ErrorMsg  string          `json:"errormsg"`   //返回错误消息
+	Raw       json.RawMessage `json:"-"`          //原始的JSON返回，只在SetKeepRaw(true)时保留
+}
+
+func (s *Status) setRaw(raw []byte) {
+	s.Raw = raw
+}
+
+//OK 请求是否成功
+func (s Status) OK() bool {
+	return s.ErrorCode == 0
+}
+
+//Err 将返回状态码转换为错误，成功时返回nil，失败时返回*APIError
+func (s Status) Err() error {
+	if s.OK() {
+		return nil
+	}
+	return &APIError{Code: s.ErrorCode, Msg: s.ErrorMsg}
+}
+
+//APIError 接口返回的非0状态码
+type APIError struct {
+	Code int    //返回状态码
+	Msg  string //返回错误消息
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("youtu: errorcode %d: %s", e.Code, e.Msg)
+}
+
+//statusError 嵌入了Status的返回，Rsp可以覆盖Err将状态码转换为特定的错误
+type statusError interface {
+	Err() error
+}
+
+//rawSetter 嵌入了Status的返回
+type rawSetter interface {
+	setRaw(raw []byte)
+}
+
+//rspError 返回rsp中的状态码对应的错误，rsp没有嵌入Status时(如Call使用的map)从body中解析
+func rspError(rsp interface{}, body []byte) error {
+	if s, ok := rsp.(statusError); ok {
+		return s.Err()
+	}
+	var s Status
+	if err := json.Unmarshal(body, &s); err != nil {
+		return nil
+	}
+	return s.Err()
+}
@@ -0,0 +1,448 @@
+/*
+* File Name:	sync.go
+* Description:  按照本地目录同步人脸库
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-30
+ */
+
+package youtu
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	//ManifestFile 同步清单的默认文件名，保存在同步目录下
+	ManifestFile = ".youtu-manifest.json"
+)
+
+//syncExts 同步时作为人脸图片的文件扩展名
+var syncExts = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".bmp":  true,
+	".gif":  true,
+}
+
+//Manifest 同步清单，记录本地图片(按内容哈希)对应的face_id，未修改的图片不会重复上传
+type Manifest struct {
+	Persons map[string]map[string]string `json:"persons"` //person_id -> 图片sha1 -> face_id
+}
+
+//LoadManifest 读取同步清单，文件不存在时返回空清单
+func LoadManifest(path string) (*Manifest, error) {
+	m := &Manifest{Persons: make(map[string]map[string]string)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	if m.Persons == nil {
+		m.Persons = make(map[string]map[string]string)
+	}
+	return m, nil
+}
+
+//Save 保存同步清单
+func (m *Manifest) Save(path string) error {
+	return writeJSONFile(path, m)
+}
+
+//writeJSONFile 以JSON保存v，先写入临时文件再重命名，避免中断时损坏文件
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+//faceID 返回个体中图片hash对应的face_id
+func (m *Manifest) faceID(personID, hash string) string {
+	return m.Persons[personID][hash]
+}
+
+func (m *Manifest) set(personID, hash, faceID string) {
+	faces := m.Persons[personID]
+	if faces == nil {
+		faces = make(map[string]string)
+		m.Persons[personID] = faces
+	}
+	faces[hash] = faceID
+}
+
+//remove 删除个体中face_id对应的记录
+func (m *Manifest) remove(personID, faceID string) {
+	for hash, id := range m.Persons[personID] {
+		if id == faceID {
+			delete(m.Persons[personID], hash)
+		}
+	}
+}
+
+//SyncFace 同步的一张人脸图片
+type SyncFace struct {
+	PersonID string //所属个体
+	Path     string //本地图片路径，删除远端人脸时为空
+	Hash     string //图片内容的sha1
+	FaceID   string //远端的face_id，新增的图片在同步完成后填入
+}
+
+//SyncPerson 同步的一个个体
+type SyncPerson struct {
+	PersonID string     //个体id，即个体目录名
+	GroupIDs []string   //所属的组，即包含个体目录的组目录
+	Faces    []SyncFace //个体的图片
+}
+
+//SyncPlan 本地目录与人脸库的差异，也是Sync执行的操作
+type SyncPlan struct {
+	NewPersons []SyncPerson //需要创建的个体
+	AddFaces   []SyncFace   //需要加入已有个体的图片
+	SetGroups  []SyncPerson //所属组与目录不一致的个体
+	DelFaces   []SyncFace   //本地已删除的人脸，只在Prune时删除
+	DelPersons []string     //本地已删除的个体，只在Prune时删除
+}
+
+//Empty 本地目录与人脸库是否一致
+func (p *SyncPlan) Empty() bool {
+	return len(p.NewPersons) == 0 && len(p.AddFaces) == 0 && len(p.SetGroups) == 0 &&
+		len(p.DelFaces) == 0 && len(p.DelPersons) == 0
+}
+
+//SyncOptions 同步选项
+type SyncOptions struct {
+	DryRun   bool   //只计算差异，不修改人脸库和清单
+	Prune    bool   //删除本地已不存在的人脸和个体
+	Manifest string //同步清单路径，为空时使用目录下的ManifestFile
+}
+
+//localPerson 本地目录中的个体
+type localPerson struct {
+	groups []string
+	faces  []SyncFace
+}
+
+//Sync 按照root目录同步人脸库，目录结构为root/组id/个体id/*.jpg，
+//同一个体可以出现在多个组目录中。返回计算出的差异，DryRun时不执行；
+//部分个体失败时返回*GroupError，清单中保留已经成功的操作
+func (y *Youtu) Sync(root string, opt SyncOptions) (plan *SyncPlan, err error) {
+	if opt.Manifest == "" {
+		opt.Manifest = filepath.Join(root, ManifestFile)
+	}
+	manifest, err := LoadManifest(opt.Manifest)
+	if err != nil {
+		return
+	}
+	local, err := scanSyncDir(root)
+	if err != nil {
+		return
+	}
+	remoteGroups, remoteFaces, err := y.galleryState()
+	if err != nil {
+		return
+	}
+	plan = syncDiff(local, remoteGroups, remoteFaces, manifest, opt.Prune)
+	if opt.DryRun {
+		return
+	}
+	err = y.applySync(plan, manifest)
+	if serr := manifest.Save(opt.Manifest); err == nil {
+		err = serr
+	}
+	return
+}
+
+//scanSyncDir 读取root/组id/个体id/*.jpg，计算每张图片的sha1
+func scanSyncDir(root string) (map[string]*localPerson, error) {
+	persons := make(map[string]*localPerson)
+	groups, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		if !g.IsDir() || strings.HasPrefix(g.Name(), ".") {
+			continue
+		}
+		dirs, err := ioutil.ReadDir(filepath.Join(root, g.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range dirs {
+			if !d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+				continue
+			}
+			p := persons[d.Name()]
+			if p == nil {
+				p = &localPerson{}
+				persons[d.Name()] = p
+			}
+			p.groups = append(p.groups, g.Name())
+			dir := filepath.Join(root, g.Name(), d.Name())
+			files, err := ioutil.ReadDir(dir)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range files {
+				if f.IsDir() || !syncExts[strings.ToLower(filepath.Ext(f.Name()))] {
+					continue
+				}
+				path := filepath.Join(dir, f.Name())
+				hash, err := fileHash(path)
+				if err != nil {
+					return nil, err
+				}
+				p.addFace(SyncFace{PersonID: d.Name(), Path: path, Hash: hash})
+			}
+		}
+	}
+	return persons, nil
+}
+
+//addFace 加入图片，同一个体在多个组目录中的相同图片只保留一张
+func (p *localPerson) addFace(face SyncFace) {
+	for _, f := range p.faces {
+		if f.Hash == face.Hash {
+			return
+		}
+	}
+	p.faces = append(p.faces, face)
+}
+
+//fileHash 计算文件内容的sha1
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha1.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+//galleryState 获取人脸库中每个个体所属的组和人脸
+func (y *Youtu) galleryState() (groups, faces map[string][]string, err error) {
+	groupRsp, err := y.GetGroupIDs()
+	if err != nil {
+		return
+	}
+	groups = make(map[string][]string)
+	faces = make(map[string][]string)
+	for _, groupID := range groupRsp.GroupIDs {
+		personRsp, err := y.GetPersonIDs(groupID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, personID := range personRsp.PersonIDs {
+			groups[personID] = append(groups[personID], groupID)
+		}
+	}
+	for personID := range groups {
+		faceRsp, err := y.GetFaceIDs(personID)
+		if err != nil {
+			return nil, nil, err
+		}
+		faces[personID] = faceRsp.FaceIDs
+	}
+	return
+}
+
+//syncDiff 比较本地目录和人脸库，清单中已经不存在的face_id会被移除
+func syncDiff(local map[string]*localPerson, remoteGroups, remoteFaces map[string][]string, m *Manifest, prune bool) *SyncPlan {
+	plan := &SyncPlan{}
+	ids := make([]string, 0, len(local))
+	for personID := range local {
+		ids = append(ids, personID)
+	}
+	sort.Strings(ids)
+	for _, personID := range ids {
+		p := local[personID]
+		groups, ok := remoteGroups[personID]
+		if !ok {
+			if len(p.faces) > 0 {
+				plan.NewPersons = append(plan.NewPersons, SyncPerson{PersonID: personID, GroupIDs: p.groups, Faces: p.faces})
+			}
+			continue
+		}
+		remote := make(map[string]bool)
+		for _, faceID := range remoteFaces[personID] {
+			remote[faceID] = true
+		}
+		keep := make(map[string]bool)
+		for _, face := range p.faces {
+			if faceID := m.faceID(personID, face.Hash); remote[faceID] {
+				keep[faceID] = true
+				continue
+			}
+			plan.AddFaces = append(plan.AddFaces, face)
+		}
+		if prune {
+			for _, faceID := range remoteFaces[personID] {
+				if !keep[faceID] {
+					plan.DelFaces = append(plan.DelFaces, SyncFace{PersonID: personID, FaceID: faceID})
+				}
+			}
+		}
+		if !sameStrings(groups, p.groups) {
+			plan.SetGroups = append(plan.SetGroups, SyncPerson{PersonID: personID, GroupIDs: p.groups, Faces: p.faces})
+		}
+		for _, faceID := range m.Persons[personID] {
+			if !remote[faceID] {
+				m.remove(personID, faceID)
+			}
+		}
+	}
+	if prune {
+		for personID := range remoteGroups {
+			if _, ok := local[personID]; !ok {
+				plan.DelPersons = append(plan.DelPersons, personID)
+			}
+		}
+		sort.Strings(plan.DelPersons)
+	}
+	for personID := range m.Persons {
+		if _, ok := remoteGroups[personID]; !ok {
+			delete(m.Persons, personID)
+		}
+	}
+	return plan
+}
+
+//applySync 执行同步，成功的操作记录在清单中。
+//先加入新图片再删除旧人脸，避免个体在同步过程中没有人脸
+func (y *Youtu) applySync(plan *SyncPlan, m *Manifest) error {
+	gerr := &GroupError{Op: "Sync", Failed: make(map[string]error)}
+	touched := make(map[string]bool)
+	fail := func(id string, err error) {
+		touched[id] = true
+		if _, ok := gerr.Failed[id]; !ok {
+			gerr.Failed[id] = err
+		}
+	}
+	for i := range plan.NewPersons {
+		p := &plan.NewPersons[i]
+		touched[p.PersonID] = true
+		first := &p.Faces[0]
+		rsp, err := y.NewPerson(p.PersonID, p.PersonID, p.GroupIDs, ImageFile(first.Path), "")
+		if err != nil {
+			fail(p.PersonID, err)
+			continue
+		}
+		first.FaceID = rsp.FaceID
+		m.set(p.PersonID, first.Hash, rsp.FaceID)
+		for j := range p.Faces[1:] {
+			if err = y.syncAddFace(&p.Faces[j+1], m); err != nil {
+				fail(p.PersonID, err)
+			}
+		}
+	}
+	for i := range plan.AddFaces {
+		face := &plan.AddFaces[i]
+		touched[face.PersonID] = true
+		if err := y.syncAddFace(face, m); err != nil {
+			fail(face.PersonID, err)
+		}
+	}
+	delFaces := make(map[string][]string)
+	var delOrder []string
+	for _, face := range plan.DelFaces {
+		if delFaces[face.PersonID] == nil {
+			delOrder = append(delOrder, face.PersonID)
+		}
+		delFaces[face.PersonID] = append(delFaces[face.PersonID], face.FaceID)
+	}
+	for _, personID := range delOrder {
+		touched[personID] = true
+		if _, err := y.DelFace(personID, delFaces[personID]); err != nil {
+			fail(personID, err)
+			continue
+		}
+		for _, faceID := range delFaces[personID] {
+			m.remove(personID, faceID)
+		}
+	}
+	//修改组需要用本地图片重新创建个体，在同步人脸之后进行
+	for _, p := range plan.SetGroups {
+		touched[p.PersonID] = true
+		store := manifestStore{m: m, personID: p.PersonID, faces: p.Faces}
+		if err := y.SetPersonGroups(p.PersonID, p.GroupIDs, store); err != nil {
+			fail(p.PersonID, err)
+		}
+	}
+	for _, personID := range plan.DelPersons {
+		touched[personID] = true
+		if _, err := y.DelPerson(personID); err != nil {
+			fail(personID, err)
+			continue
+		}
+		delete(m.Persons, personID)
+	}
+	for personID := range touched {
+		if _, ok := gerr.Failed[personID]; !ok {
+			gerr.Done = append(gerr.Done, personID)
+		}
+	}
+	sort.Strings(gerr.Done)
+	return gerr.err()
+}
+
+//syncAddFace 加入一张图片，每次只上传一张以便对应返回的face_id
+func (y *Youtu) syncAddFace(face *SyncFace, m *Manifest) error {
+	rsp, err := y.AddFace(face.PersonID, []Image{ImageFile(face.Path)}, "")
+	if err != nil {
+		return err
+	}
+	if len(rsp.FaceIDs) != 1 {
+		return fmt.Errorf("%s: no face added", face.Path)
+	}
+	face.FaceID = rsp.FaceIDs[0]
+	m.set(face.PersonID, face.Hash, face.FaceID)
+	return nil
+}
+
+//manifestStore 通过清单找到face_id对应的本地图片，重新创建个体后更新清单
+type manifestStore struct {
+	m        *Manifest
+	personID string
+	faces    []SyncFace
+}
+
+func (s manifestStore) FaceImage(faceID string) (Image, error) {
+	for _, face := range s.faces {
+		if s.m.faceID(s.personID, face.Hash) == faceID {
+			return ImageFile(face.Path), nil
+		}
+	}
+	return Image{}, ErrNoFaceImage
+}
+
+func (s manifestStore) Rename(oldFaceID, newFaceID string) error {
+	for hash, faceID := range s.m.Persons[s.personID] {
+		if faceID == oldFaceID {
+			s.m.Persons[s.personID][hash] = newFaceID
+		}
+	}
+	return nil
+}
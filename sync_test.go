@@ -0,0 +1,88 @@
+/*
+* File Name:	sync_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-30
+ */
+
+package youtu
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//copyTestImage 将testdata中的图片复制到dir/name
+func copyTestImage(t *testing.T, src, dir, name string) {
+	data, err := ioutil.ReadFile(testDataDir + src)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %s", err)
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %s", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %s", err)
+	}
+}
+
+func TestSync(t *testing.T) {
+	y, g, done := newFakeYoutu()
+	defer done()
+	root, err := ioutil.TempDir("", "youtu")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+	defer os.RemoveAll(root)
+	copyTestImage(t, "imageA.jpg", filepath.Join(root, "dev", "alice"), "a.jpg")
+	copyTestImage(t, "imageB.jpg", filepath.Join(root, "dev", "bob"), "b.jpg")
+	copyTestImage(t, "imageB.jpg", filepath.Join(root, "ops", "bob"), "b.jpg")
+
+	plan, err := y.Sync(root, SyncOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Sync dry run failed: %s\n", err)
+	}
+	if len(plan.NewPersons) != 2 || g.calls["newperson"] != 0 {
+		t.Errorf("dry run plan: %#v, calls: %v\n", plan, g.calls)
+	}
+
+	if _, err = y.Sync(root, SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %s\n", err)
+	}
+	if bob := g.persons["bob"]; bob == nil || !sameStrings(bob.groups, []string{"dev", "ops"}) || len(bob.faces) != 1 {
+		t.Errorf("bob: %#v\n", bob)
+	}
+
+	//清单中记录了图片，未修改时不重复上传
+	plan, err = y.Sync(root, SyncOptions{})
+	if err != nil || !plan.Empty() || g.calls["addface"] != 0 || g.calls["newperson"] != 2 {
+		t.Errorf("second Sync: %#v, %v, calls: %v\n", plan, err, g.calls)
+	}
+
+	//替换alice的图片，bob离开ops组
+	os.Remove(filepath.Join(root, "dev", "alice", "a.jpg"))
+	copyTestImage(t, "imageD.jpg", filepath.Join(root, "dev", "alice"), "d.jpg")
+	os.RemoveAll(filepath.Join(root, "ops"))
+	plan, err = y.Sync(root, SyncOptions{})
+	if err != nil || len(plan.AddFaces) != 1 || len(plan.DelFaces) != 0 || len(plan.SetGroups) != 1 {
+		t.Errorf("Sync without prune: %#v, %v\n", plan, err)
+	}
+	if len(g.persons["alice"].faces) != 2 || !sameStrings(g.persons["bob"].groups, []string{"dev"}) {
+		t.Errorf("alice: %#v, bob: %#v\n", g.persons["alice"], g.persons["bob"])
+	}
+
+	os.RemoveAll(filepath.Join(root, "dev", "bob"))
+	plan, err = y.Sync(root, SyncOptions{Prune: true})
+	if err != nil || len(plan.DelFaces) != 1 || len(plan.DelPersons) != 1 {
+		t.Errorf("Sync with prune: %#v, %v\n", plan, err)
+	}
+	if _, ok := g.persons["bob"]; ok || len(g.persons["alice"].faces) != 1 {
+		t.Errorf("persons after prune: %#v\n", g.persons)
+	}
+	m, err := LoadManifest(filepath.Join(root, ManifestFile))
+	if err != nil || len(m.Persons) != 1 || len(m.Persons["alice"]) != 1 {
+		t.Errorf("manifest: %#v, %v\n", m, err)
+	}
+}
@@ -0,0 +1,248 @@
+/*
+* File Name:	validate.go
+* Description:  上传前的图片校验
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-08
+ */
+
+package youtu
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"reflect"
+)
+
+const (
+	//ImageMaxSize 上传图片数据的最大字节数
+	ImageMaxSize = 1 << 20
+	//ImageMaxSide 上传图片的最大边长(像素)
+	ImageMaxSide = 4096
+)
+
+var (
+	//ErrImageTooLarge 图片数据超过ImageMaxSize
+	ErrImageTooLarge = errors.New("image too large")
+	//ErrImageDimension 图片宽高为0或超过ImageMaxSide
+	ErrImageDimension = errors.New("image dimension out of range")
+	//ErrUnsupportedFormat 不支持的图片格式，只支持JPEG/PNG/BMP/GIF
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	//ErrInvalidImage 无法解析图片头部
+	ErrInvalidImage = errors.New("invalid image")
+)
+
+//ImageFormat 图片格式
+type ImageFormat string
+
+const (
+	//FormatJPEG JPEG格式
+	FormatJPEG ImageFormat = "jpeg"
+	//FormatPNG PNG格式
+	FormatPNG ImageFormat = "png"
+	//FormatBMP BMP格式
+	FormatBMP ImageFormat = "bmp"
+	//FormatGIF GIF格式
+	FormatGIF ImageFormat = "gif"
+)
+
+//sniffFormat 根据文件头判断图片格式
+func sniffFormat(head []byte) (ImageFormat, error) {
+	switch {
+	case bytes.HasPrefix(head, []byte("\xff\xd8\xff")):
+		return FormatJPEG, nil
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return FormatPNG, nil
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return FormatGIF, nil
+	case bytes.HasPrefix(head, []byte("BM")):
+		return FormatBMP, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+//bmpConfig 解析BMP文件头中的宽高
+func bmpConfig(r io.Reader) (cfg image.Config, err error) {
+	var h [26]byte
+	if _, err = io.ReadFull(r, h[:]); err != nil {
+		return
+	}
+	if binary.LittleEndian.Uint32(h[14:18]) == 12 {
+		//BITMAPCOREHEADER
+		cfg.Width = int(binary.LittleEndian.Uint16(h[18:20]))
+		cfg.Height = int(binary.LittleEndian.Uint16(h[20:22]))
+		return
+	}
+	cfg.Width = int(int32(binary.LittleEndian.Uint32(h[18:22])))
+	cfg.Height = int(int32(binary.LittleEndian.Uint32(h[22:26])))
+	if cfg.Height < 0 {
+		//自上而下存储的位图高度为负数
+		cfg.Height = -cfg.Height
+	}
+	return
+}
+
+//decodeConfig 读取图片头部，返回图片格式和宽高
+func decodeConfig(r io.Reader) (format ImageFormat, cfg image.Config, err error) {
+	var head [8]byte
+	n, err := io.ReadFull(r, head[:])
+	if err == io.EOF {
+		err = ErrEmptyImage
+		return
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return
+	}
+	if format, err = sniffFormat(head[:n]); err != nil {
+		return
+	}
+	r = io.MultiReader(bytes.NewReader(head[:n]), r)
+	switch format {
+	case FormatJPEG:
+		cfg, err = jpeg.DecodeConfig(r)
+	case FormatPNG:
+		cfg, err = png.DecodeConfig(r)
+	case FormatGIF:
+		cfg, err = gif.DecodeConfig(r)
+	case FormatBMP:
+		cfg, err = bmpConfig(r)
+	}
+	if err != nil {
+		err = ErrInvalidImage
+	}
+	return
+}
+
+//validate 在上传之前校验图片格式、宽高和大小，远程URL由优图服务器校验。
+//io.Reader来源的图片最多读取ImageMaxSize+1字节到内存中校验
+func (img Image) validate() error {
+	var r io.Reader
+	switch {
+	case img.IsURL():
+		return nil
+	case len(img.data) > 0:
+		if len(img.data) > ImageMaxSize {
+			return ErrImageTooLarge
+		}
+		r = bytes.NewReader(img.data)
+	case img.reader != nil:
+		head, err := img.reader.fill(ImageMaxSize + 1)
+		if err != nil {
+			return err
+		}
+		if len(head) > ImageMaxSize {
+			return ErrImageTooLarge
+		}
+		r = bytes.NewReader(head)
+	case img.path != "":
+		f, err := os.Open(img.path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if fi.Size() > ImageMaxSize {
+			return ErrImageTooLarge
+		}
+		r = f
+	default:
+		return ErrEmptyImage
+	}
+	_, cfg, err := decodeConfig(r)
+	if err != nil {
+		return err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > ImageMaxSide || cfg.Height > ImageMaxSide {
+		return ErrImageDimension
+	}
+	return nil
+}
+
+var (
+	imageType = reflect.TypeOf(Image{})
+	videoType = reflect.TypeOf(Video{})
+)
+
+//validateRequest 校验请求中所有的Image和Video字段
+func validateRequest(req interface{}) error {
+	return validateValue(reflect.ValueOf(req))
+}
+
+func validateValue(v reflect.Value) error {
+	switch {
+	case !v.IsValid():
+		return nil
+	case v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return validateValue(v.Elem())
+	case v.Type() == imageType:
+		img := v.Interface().(Image)
+		if img.isZero() {
+			return nil
+		}
+		return img.validate()
+	case v.Type() == videoType:
+		video := v.Interface().(Video)
+		if video.isZero() {
+			return nil
+		}
+		return video.validate()
+	case v.Kind() == reflect.Struct:
+		for _, f := range structFields(v.Type()) {
+			fv, ok := fieldByIndex(v, f.index)
+			if !ok {
+				continue
+			}
+			if err := validateValue(fv); err != nil {
+				return err
+			}
+		}
+	case v.Kind() == reflect.Map:
+		for _, k := range v.MapKeys() {
+			if err := validateValue(v.MapIndex(k)); err != nil {
+				return err
+			}
+		}
+	case isList(v):
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//headReader 缓存校验时读取的数据，发送时先读出缓存的数据
+type headReader struct {
+	r    io.Reader
+	head bytes.Buffer
+}
+
+func (h *headReader) Read(p []byte) (int, error) {
+	if h.head.Len() > 0 {
+		return h.head.Read(p)
+	}
+	return h.r.Read(p)
+}
+
+//fill 读取数据直到缓存了limit字节或读完，返回缓存的数据。多次调用不会重复读取
+func (h *headReader) fill(limit int64) ([]byte, error) {
+	if n := limit - int64(h.head.Len()); n > 0 {
+		if _, err := h.head.ReadFrom(io.LimitReader(h.r, n)); err != nil {
+			return nil, err
+		}
+	}
+	return h.head.Bytes(), nil
+}
@@ -0,0 +1,103 @@
+/*
+* File Name:	validate_test.go
+* Description:
+* Author:	Chapman Ou <ochapman.cn@gmail.com>
+* Created:	2015-09-08
+ */
+
+package youtu
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/gif"
+	"image/png"
+	"net/http"
+	"testing"
+)
+
+func encodeTestImage(t *testing.T, format ImageFormat, w, h int) []byte {
+	var buf bytes.Buffer
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	switch format {
+	case FormatPNG:
+		png.Encode(&buf, img)
+	case FormatGIF:
+		gif.Encode(&buf, img, nil)
+	case FormatBMP:
+		buf.WriteString("BM")
+		binary.Write(&buf, binary.LittleEndian, [3]uint32{0, 0, 54})
+		binary.Write(&buf, binary.LittleEndian, [3]int32{40, int32(w), int32(-h)})
+	default:
+		t.Fatalf("unsupported format %s", format)
+	}
+	return buf.Bytes()
+}
+
+func TestImageValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		img  Image
+		err  error
+	}{
+		{"jpeg", ImageFile(testDataDir + "imageA.jpg"), nil},
+		{"png", ImageData(encodeTestImage(t, FormatPNG, 64, 32)), nil},
+		{"gif", ImageReader(bytes.NewReader(encodeTestImage(t, FormatGIF, 32, 64))), nil},
+		{"bmp", ImageData(encodeTestImage(t, FormatBMP, 640, 480)), nil},
+		{"url", ImageURL("http://example.com/a.jpg"), nil},
+		{"text", ImageData([]byte("not an image")), ErrUnsupportedFormat},
+		{"truncated", ImageData([]byte("\x89PNG\r\n\x1a\n")), ErrInvalidImage},
+		{"empty", ImageReader(bytes.NewReader(nil)), ErrEmptyImage},
+		{"dimension", ImageData(encodeTestImage(t, FormatBMP, ImageMaxSide+1, 10)), ErrImageDimension},
+		{"size", ImageData(append(encodeTestImage(t, FormatPNG, 8, 8), make([]byte, ImageMaxSize)...)), ErrImageTooLarge},
+		{"reader size", ImageReader(bytes.NewReader(append(encodeTestImage(t, FormatPNG, 8, 8), make([]byte, ImageMaxSize)...))), ErrImageTooLarge},
+	}
+	for _, tt := range tests {
+		if err := tt.img.validate(); err != tt.err {
+			t.Errorf("%s: validate() = %v, want %v\n", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestValidateBeforeRequest(t *testing.T) {
+	y, ts := newTestYoutu(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s\n", r.URL.Path)
+	})
+	defer ts.Close()
+
+	images := []Image{ImageFile(testDataDir + "imageA.jpg"), ImageData([]byte("not an image"))}
+	if _, err := y.AddFace("ochapman", images, ""); err != ErrUnsupportedFormat {
+		t.Errorf("AddFace err = %v, want %v\n", err, ErrUnsupportedFormat)
+	}
+	large := ImageReader(bytes.NewReader(append(encodeTestImage(t, FormatPNG, 8, 8), make([]byte, ImageMaxSize)...)))
+	if _, err := y.DetectFace(large, false); err != ErrImageTooLarge {
+		t.Errorf("DetectFace err = %v, want %v\n", err, ErrImageTooLarge)
+	}
+	req := map[string]interface{}{"app_id": y.AppID(), "images": []interface{}{images[1]}}
+	if err := y.Call(context.Background(), "imageapi/x", req, &Status{}); err != ErrUnsupportedFormat {
+		t.Errorf("Call err = %v, want %v\n", err, ErrUnsupportedFormat)
+	}
+	embedded := struct {
+		detectFaceReq
+		Extra int `json:"extra"`
+	}{detectFaceReq{AppID: y.AppID(), Image: images[1]}, 1}
+	if err := y.Call(context.Background(), "api/detectface", embedded, &Status{}); err != ErrUnsupportedFormat {
+		t.Errorf("Call err = %v, want %v\n", err, ErrUnsupportedFormat)
+	}
+}
+
+func TestHeadReader(t *testing.T) {
+	data := encodeTestImage(t, FormatPNG, 16, 16)
+	img := ImageReader(bytes.NewReader(data))
+	if err := img.validate(); err != nil {
+		t.Errorf("validate() failed: %s\n", err)
+		return
+	}
+	var buf bytes.Buffer
+	buf.ReadFrom(img.reader)
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("read %d bytes after validate, want %d\n", buf.Len(), len(data))
+	}
+}
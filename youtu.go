@@ -8,8 +8,9 @@
 package youtu
 
 import (
-	"encoding/base64"
+	"context"
 	"errors"
+	"sort"
 	"strconv"
 )
 
@@ -55,15 +56,26 @@ func NewAppSign(appID uint32, secretID string, secretKey string, userID string)
 
 //Youtu 存储签名和host
 type Youtu struct {
-	appSign AppSign
-	host    string
-	debug   bool //Default false
+	appSign    AppSign
+	host       string
+	debug      bool            //Default false
+	preprocess *Preprocess     //上传前的图片预处理, Default nil
+	keepRaw    bool            //在返回的Status.Raw中保留原始JSON, Default false
+	limiter    *rateLimiter    //请求频率限制, Default nil
+	ctx        context.Context //WithContext设置的请求context, Default nil
+	cache      *responseCache  //接口返回的缓存, Default nil
+	flights    *flightGroup    //合并相同请求的接口, Default nil
 }
 
 func (y *Youtu) appID() string {
 	return strconv.Itoa(int(y.appSign.appID))
 }
 
+//AppID 返回App的 API ID，使用Call时作为请求中的app_id
+func (y *Youtu) AppID() string {
+	return y.appID()
+}
+
 //Init Youtu初始化
 func Init(appSign AppSign, host string) *Youtu {
 	return &Youtu{
@@ -95,10 +107,17 @@ func (y *Youtu) SetDebug(isDebug bool) {
 	y.debug = isDebug
 }
 
+//SetKeepRaw 设置是否在返回的Status.Raw中保留原始JSON，
+//用于读取库中还没有定义的返回字段
+func (y *Youtu) SetKeepRaw(keep bool) {
+	y.keepRaw = keep
+}
+
 type detectFaceReq struct {
-	AppID string     `json:"app_id"`         //App的 API ID
-	Image string     `json:"image"`          //base64编码的二进制图片数据
-	Mode  detectMode `json:"mode,omitempty"` //检测模式 0/1 正常/大脸模式
+	AppID string     `json:"app_id"`          //App的 API ID
+	Image Image      `json:"image,omitempty"` //base64编码的二进制图片数据
+	URL   string     `json:"url,omitempty"`   //图片的url, image和url只需提供一个
+	Mode  detectMode `json:"mode,omitempty"`  //检测模式 0/1 正常/大脸模式
 }
 
 //Face 脸参数
@@ -119,33 +138,41 @@ type Face struct {
 
 //DetectFaceRsp 脸检测返回
 type DetectFaceRsp struct {
-	SessionID   string `json:"session_id"`   //相应请求的session标识符，可用于结果查询
+	Status
 	ImageID     string `json:"image_id"`     //系统中的图片标识符，用于标识用户请求中的图片
 	ImageWidth  int32  `json:"image_width"`  //请求图片的宽度
 	ImageHeight int32  `json:"image_height"` //请求图片的高度
 	Face        []Face `json:"face"`         //被检测出的人脸Face的列表
-	ErrorCode   int    `json:"errorcode"`    //返回状态值
-	ErrorMsg    string `json:"errormsg"`     //返回错误消息
 }
 
 //DetectFace 检测给定图片(Image)中的所有人脸(Face)的位置和相应的面部属性。
 //位置包括(x, y, w, h)，面部属性包括性别(gender), 年龄(age),
 //表情(expression), 眼镜(glass)和姿态(pitch，roll，yaw).
-func (y *Youtu) DetectFace(imageData []byte, isBigFace bool) (rsp DetectFaceRsp, err error) {
-	b64Image := base64.StdEncoding.EncodeToString(imageData)
+func (y *Youtu) DetectFace(image Image, isBigFace bool) (rsp DetectFaceRsp, err error) {
+	image, scale, err := y.preprocessImage(image)
+	if err != nil {
+		return
+	}
+	data, url, err := image.fields()
+	if err != nil {
+		return
+	}
 	req := detectFaceReq{
 		AppID: strconv.Itoa(int(y.appSign.appID)),
-		Image: b64Image,
+		Image: data,
+		URL:   url,
 		Mode:  mode(isBigFace),
 	}
 	err = y.interfaceRequest("detectface", req, &rsp)
+	rsp.scale(scale)
 	return
 }
 
 type faceShapeReq struct {
-	AppID string     `json:"app_id"`         //App的 API ID
-	Image string     `json:"image"`          //base64编码的二进制图片数据
-	Mode  detectMode `json:"mode,omitempty"` //检测模式 0/1 正常/大脸模式
+	AppID string     `json:"app_id"`          //App的 API ID
+	Image Image      `json:"image,omitempty"` //base64编码的二进制图片数据
+	URL   string     `json:"url,omitempty"`   //图片的url, image和url只需提供一个
+	Mode  detectMode `json:"mode,omitempty"`  //检测模式 0/1 正常/大脸模式
 }
 
 type pos struct {
@@ -166,77 +193,105 @@ type FaceShape struct {
 
 // FaceShapeRsp 返回
 type FaceShapeRsp struct {
-	SessionID   string      `json:"session_id"`   //相应请求的session标识符，可用于结果查询
+	Status
 	FaceShape   []FaceShape `json:"face_shape"`   //人脸轮廓结构体，包含所有人脸的轮廓点
 	ImageWidth  int         `json:"image_width"`  //请求图片的宽度
 	ImageHeight int         `json:"image_height"` //请求图片的高度
-	ErrorCode   int         `json:"errorcode"`    //返回状态值
-	ErrorMsg    string      `json:"errormsg"`     //返回错误消息
 }
 
 //FaceShape 对请求图片进行五官定位，计算构成人脸轮廓的88个点，包括眉毛（左右各8点）、眼睛（左右各8点）、鼻子（13点）、嘴巴（22点）、脸型轮廓（21点）
-func (y *Youtu) FaceShape(image []byte, isBigFace bool) (rsp FaceShapeRsp, err error) {
-	b64Image := base64.StdEncoding.EncodeToString(image)
+func (y *Youtu) FaceShape(image Image, isBigFace bool) (rsp FaceShapeRsp, err error) {
+	image, scale, err := y.preprocessImage(image)
+	if err != nil {
+		return
+	}
+	data, url, err := image.fields()
+	if err != nil {
+		return
+	}
 	req := faceShapeReq{
 		AppID: strconv.Itoa(int(y.appSign.appID)),
-		Image: b64Image,
+		Image: data,
+		URL:   url,
 		Mode:  mode(isBigFace),
 	}
 	err = y.interfaceRequest("faceshape", req, &rsp)
+	rsp.scale(scale)
 	return
 }
 
 type faceCompareReq struct {
 	AppID  string `json:"app_id"`
-	ImageA string `json:"imageA"` //使用base64编码的二进制图片数据A
-	ImageB string `json:"imageB"` //使用base64编码的二进制图片数据B
+	ImageA Image  `json:"imageA,omitempty"` //使用base64编码的二进制图片数据A
+	ImageB Image  `json:"imageB,omitempty"` //使用base64编码的二进制图片数据B
+	URLA   string `json:"urlA,omitempty"`   //A图片的url, imageA和urlA只需提供一个
+	URLB   string `json:"urlB,omitempty"`   //B图片的url, imageB和urlB只需提供一个
 }
 
 //FaceCompareRsp 脸比较返回
 type FaceCompareRsp struct {
+	Status
 	EyebrowSim float32 `json:"eyebrow_sim"` //眉毛的相似度。
 	EyeSim     float32 `json:"eye_sim"`     //眼睛的相似度
 	NoseSim    float32 `json:"nose_sim"`    //鼻子的相似度
 	MouthSim   float32 `json:"mouth_sim"`   //嘴巴的相似度
 	Similarity float32 `json:"similarity"`  //两个face的相似度
-	ErrorCode  int32   `json:"errorcode"`   //返回状态码
-	ErrorMsg   string  `json:"errormsg"`    //返回错误消息
 }
 
 //FaceCompare 计算两个Face的相似性以及五官相似度
-func (y *Youtu) FaceCompare(imageA, imageB []byte) (rsp FaceCompareRsp, err error) {
-	b64ImageA := base64.StdEncoding.EncodeToString(imageA)
-	b64ImageB := base64.StdEncoding.EncodeToString(imageB)
+func (y *Youtu) FaceCompare(imageA, imageB Image) (rsp FaceCompareRsp, err error) {
+	if imageA, _, err = y.preprocessImage(imageA); err != nil {
+		return
+	}
+	if imageB, _, err = y.preprocessImage(imageB); err != nil {
+		return
+	}
+	dataA, urlA, err := imageA.fields()
+	if err != nil {
+		return
+	}
+	dataB, urlB, err := imageB.fields()
+	if err != nil {
+		return
+	}
 	req := faceCompareReq{
 		AppID:  y.appID(),
-		ImageA: b64ImageA,
-		ImageB: b64ImageB,
+		ImageA: dataA,
+		ImageB: dataB,
+		URLA:   urlA,
+		URLB:   urlB,
 	}
 	err = y.interfaceRequest("facecompare", req, &rsp)
 	return
 }
 
 type faceVerifyReq struct {
-	AppID    string `json:"app_id"`    //App的 API ID
-	Image    string `json:"image"`     //使用base64编码的二进制图片数据
-	PersonID string `json:"person_id"` //待验证的Person
+	AppID    string `json:"app_id"`          //App的 API ID
+	Image    Image  `json:"image,omitempty"` //使用base64编码的二进制图片数据
+	URL      string `json:"url,omitempty"`   //图片的url, image和url只需提供一个
+	PersonID string `json:"person_id"`       //待验证的Person
 }
 
 //FaceVerifyRsp 脸验证返回
 type FaceVerifyRsp struct {
+	Status
 	Ismatch    bool    `json:"ismatch"`    //两个输入是否为同一人的判断
 	Confidence float32 `json:"confidence"` //系统对这个判断的置信度。
-	SessionID  string  `json:"session_id"` //相应请求的session标识符，可用于结果查询
-	ErrorCode  int32   `json:"errorcode"`  //返回状态码
-	ErrorMsg   string  `json:"errormsg"`   //返回错误消息
 }
 
 //FaceVerify 给定一个Face和一个Person，返回是否是同一个人的判断以及置信度。
-func (y *Youtu) FaceVerify(personID string, image []byte) (rsp FaceVerifyRsp, err error) {
-	b64Image := base64.StdEncoding.EncodeToString(image)
+func (y *Youtu) FaceVerify(personID string, image Image) (rsp FaceVerifyRsp, err error) {
+	if image, _, err = y.preprocessImage(image); err != nil {
+		return
+	}
+	data, url, err := image.fields()
+	if err != nil {
+		return
+	}
 	req := faceVerifyReq{
 		AppID:    y.appID(),
-		Image:    b64Image,
+		Image:    data,
+		URL:      url,
 		PersonID: personID,
 	}
 	err = y.interfaceRequest("faceverify", req, &rsp)
@@ -244,36 +299,122 @@ func (y *Youtu) FaceVerify(personID string, image []byte) (rsp FaceVerifyRsp, er
 }
 
 type faceIdentifyReq struct {
-	AppID   string `json:"app_id"`   //App的 API ID
-	GroupID string `json:"group_id"` //候选人组id
-	Image   string `json:"image"`    //使用base64编码的二进制图片数据
+	AppID    string   `json:"app_id"`              //App的 API ID
+	GroupID  string   `json:"group_id,omitempty"`  //候选人组id
+	GroupIDs []string `json:"group_ids,omitempty"` //候选人组id列表, group_id和group_ids只需提供一个
+	Image    Image    `json:"image,omitempty"`     //使用base64编码的二进制图片数据
+	URL      string   `json:"url,omitempty"`       //图片的url, image和url只需提供一个
+	TopN     int      `json:"topn,omitempty"`      //返回的候选人个数，0表示使用服务端默认值
 }
 
 //FaceIdentifyRsp 脸识别返回
 type FaceIdentifyRsp struct {
-	SessionID  string  `json:"session_id"` //相应请求的session标识符，可用于结果查询
-	PersonID   string  `json:"person_id"`  //识别结果，person_id
-	FaceID     string  `json:"face_id"`    //识别的face_id
-	Confidence float32 `json:"confidence"` //置信度
-	ErrorCode  int     `json:"errorcode"`  //返回状态码
-	ErrorMsg   string  `json:"errormsg"`   //返回错误消息
+	Status
+	PersonID   string      `json:"person_id"`  //识别结果，person_id
+	FaceID     string      `json:"face_id"`    //识别的face_id
+	Confidence float32     `json:"confidence"` //置信度
+	Candidates []Candidate `json:"candidates"` //候选人列表，按置信度从高到低排序
 }
 
 //FaceIdentify 对于一个待识别的人脸图片，在一个Group中识别出最相似的Person作为其身份返回
-func (y *Youtu) FaceIdentify(groupID string, image []byte) (rsp FaceIdentifyRsp, err error) {
-	b64Image := base64.StdEncoding.EncodeToString(image)
+func (y *Youtu) FaceIdentify(groupID string, image Image) (rsp FaceIdentifyRsp, err error) {
 	req := faceIdentifyReq{
 		AppID:   y.appID(),
 		GroupID: groupID,
-		Image:   b64Image,
+	}
+	return y.faceIdentify(req, image)
+}
+
+//FaceIdentifyTopN 对于一个待识别的人脸图片，在groupIDs指定的组中识别出最相似的topN个Person，
+//结果按置信度从高到低排列在Candidates中
+func (y *Youtu) FaceIdentifyTopN(groupIDs []string, image Image, topN int) (rsp FaceIdentifyRsp, err error) {
+	req := faceIdentifyReq{
+		AppID:    y.appID(),
+		GroupIDs: groupIDs,
+		TopN:     topN,
+	}
+	return y.faceIdentify(req, image)
+}
+
+func (y *Youtu) faceIdentify(req faceIdentifyReq, image Image) (rsp FaceIdentifyRsp, err error) {
+	if image, _, err = y.preprocessImage(image); err != nil {
+		return
+	}
+	if req.Image, req.URL, err = image.fields(); err != nil {
+		return
 	}
 	err = y.interfaceRequest("faceidentify", req, &rsp)
+	sortCandidates(rsp.Candidates)
 	return
 }
 
+type multiFaceIdentifyReq struct {
+	AppID    string   `json:"app_id"`          //App的 API ID
+	GroupIDs []string `json:"group_ids"`       //候选人组id列表
+	Image    Image    `json:"image,omitempty"` //使用base64编码的二进制图片数据
+	URL      string   `json:"url,omitempty"`   //图片的url, image和url只需提供一个
+	TopN     int      `json:"topn,omitempty"`  //每个人脸返回的候选人个数，0表示使用服务端默认值
+}
+
+//Candidate 人脸识别的候选人
+type Candidate struct {
+	PersonID   string  `json:"person_id"`  //候选人的person_id
+	FaceID     string  `json:"face_id"`    //最相似的face_id
+	Confidence float32 `json:"confidence"` //置信度
+	Tag        string  `json:"tag"`        //候选人的备注信息
+}
+
+//MultiFaceResult 多人脸识别中单个人脸的结果
+type MultiFaceResult struct {
+	FaceRect   Face        `json:"face_rect"`  //人脸框，只有X/Y/Width/Height有效
+	Candidates []Candidate `json:"candidates"` //候选人列表，按置信度从高到低排序
+}
+
+//MultiFaceIdentifyRsp 多人脸识别返回
+type MultiFaceIdentifyRsp struct {
+	Status
+	Results   []MultiFaceResult `json:"results"`    //检测出的每个人脸的识别结果
+	GroupSize int               `json:"group_size"` //候选人组中的人数
+	TimeMs    int               `json:"time_ms"`    //服务端处理时间(毫秒)
+}
+
+//MultiFaceIdentify 检测图片中的所有人脸，对每个人脸在groupIDs指定的组中识别出最相似的topN个Person
+func (y *Youtu) MultiFaceIdentify(groupIDs []string, image Image, topN int) (rsp MultiFaceIdentifyRsp, err error) {
+	image, scale, err := y.preprocessImage(image)
+	if err != nil {
+		return
+	}
+	data, url, err := image.fields()
+	if err != nil {
+		return
+	}
+	req := multiFaceIdentifyReq{
+		AppID:    y.appID(),
+		GroupIDs: groupIDs,
+		Image:    data,
+		URL:      url,
+		TopN:     topN,
+	}
+	err = y.interfaceRequest("multifaceidentify", req, &rsp)
+	for i := range rsp.Results {
+		r := &rsp.Results[i]
+		r.FaceRect.scale(scale)
+		sortCandidates(r.Candidates)
+	}
+	return
+}
+
+//sortCandidates 按置信度从高到低排序候选人
+func sortCandidates(candidates []Candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+}
+
 type newPersonReq struct {
-	AppID      string   `json:"app_id"` //App的 API ID
-	Image      string   `json:"image"`  //使用base64编码的二进制图片数据
+	AppID      string   `json:"app_id"`          //App的 API ID
+	Image      Image    `json:"image,omitempty"` //使用base64编码的二进制图片数据
+	URL        string   `json:"url,omitempty"`   //图片的url, image和url只需提供一个
 	PersonID   string   `json:"person_id"`
 	GroupIDs   []string `json:"group_ids"`             // 	加入到组的列表
 	PersonName string   `json:"person_name,omitempty"` //名字
@@ -282,28 +423,44 @@ type newPersonReq struct {
 
 //NewPersonRsp 个体创建返回
 type NewPersonRsp struct {
-	SessionID  string `json:"session_id"`  //相应请求的session标识符
+	Status
 	SucGroup   int    `json:"suc_group"`   //成功被加入的group数量
 	SucFace    int    `json:"suc_face"`    //成功加入的face数量
 	PersonName string `json:"person_name"` //相应person的name
 	PersonID   string `json:"person_id"`   //相应person的id
 	FaceID     string `json:"face_id"`     //创建所用图片生成的face_id
-	ErrorCode  int    `json:"errorcode"`   //返回码
-	ErrorMsg   string `json:"errormsg"`    //返回错误消息
 }
 
 //NewPerson 创建一个Person，并将Person放置到group_ids指定的组当中
-func (y *Youtu) NewPerson(personID string, personName string, groupIDs []string, image []byte, tag string) (rsp NewPersonRsp, err error) {
-	b64Image := base64.StdEncoding.EncodeToString(image)
-	req := newPersonReq{
+func (y *Youtu) NewPerson(personID string, personName string, groupIDs []string, image Image, tag string) (rsp NewPersonRsp, err error) {
+	req, err := y.newPersonReq(personID, personName, groupIDs, image, tag)
+	if err != nil {
+		return
+	}
+	err = y.interfaceRequest("newperson", req, &rsp)
+	return
+}
+
+func (y *Youtu) newPersonReq(personID string, personName string, groupIDs []string, image Image, tag string) (req newPersonReq, err error) {
+	if err = checkTag(tag); err != nil {
+		return
+	}
+	if image, _, err = y.preprocessImage(image); err != nil {
+		return
+	}
+	data, url, err := image.fields()
+	if err != nil {
+		return
+	}
+	req = newPersonReq{
 		AppID:      y.appID(),
 		PersonID:   personID,
-		Image:      b64Image,
+		Image:      data,
+		URL:        url,
 		GroupIDs:   groupIDs,
 		PersonName: personName,
 		Tag:        tag,
 	}
-	err = y.interfaceRequest("newperson", req, &rsp)
 	return
 }
 
@@ -314,10 +471,8 @@ type delPersonReq struct {
 
 //DelPersonRsp 删除个体返回
 type DelPersonRsp struct {
-	SessionID string `json:"session_id"` //相应请求的session标识符
-	Deleted   int    `json:"deleted"`    //成功删除的Person数量
-	ErrorCode int    `json:"errorcode"`  //返回状态码
-	ErrorMsg  string `json:"errormsg"`   //返回错误消息
+	Status
+	Deleted int `json:"deleted"` //成功删除的Person数量
 }
 
 //DelPerson 删除一个Person
@@ -331,35 +486,57 @@ func (y *Youtu) DelPerson(personID string) (rsp DelPersonRsp, err error) {
 }
 
 type addFaceReq struct {
-	AppID    string   `json:"app_id"`        //App的 API ID
-	PersonID string   `json:"person_id"`     //String 	待增加人脸的个体id
-	Images   []string `json:"images"`        //base64编码的二进制图片数据构成的数组
-	Tag      string   `json:"tag,omitempty"` //备注信息
+	AppID    string   `json:"app_id"`           //App的 API ID
+	PersonID string   `json:"person_id"`        //String 	待增加人脸的个体id
+	Images   []Image  `json:"images,omitempty"` //base64编码的二进制图片数据构成的数组
+	URLs     []string `json:"urls,omitempty"`   //图片url构成的数组, images和urls只需提供一个
+	Tag      string   `json:"tag,omitempty"`    //备注信息
 }
 
 //AddFaceRsp 增加人脸返回
 type AddFaceRsp struct {
-	SessionID string   `json:"session_id"` //相应请求的session标识符
-	Added     int      `json:"added"`      //成功加入的face数量
-	FaceIDs   []string `json:"face_ids"`   //增加的人脸ID列表
-	ErrorCode int      `json:"errorcode"`  //返回状态码
-	ErrorMsg  string   `json:"errormsg"`   //返回错误消息
+	Status
+	Added   int      `json:"added"`    //成功加入的face数量
+	FaceIDs []string `json:"face_ids"` //增加的人脸ID列表
 }
 
 //AddFace 将一组Face加入到一个Person中。注意，一个Face只能被加入到一个Person中。
 //一个Person最多允许包含10000个Face
-func (y *Youtu) AddFace(personID string, images [][]byte, tag string) (rsp AddFaceRsp, err error) {
-	b64Images := make([]string, len(images))
-	for i, img := range images {
-		b64Images[i] = base64.StdEncoding.EncodeToString([]byte(img))
+//images中的图片数据和url会分别放在images和urls中上传，返回的FaceIDs中图片数据加入的人脸在前，url加入的在后
+func (y *Youtu) AddFace(personID string, images []Image, tag string) (rsp AddFaceRsp, err error) {
+	req, err := y.addFaceReq(personID, images, tag)
+	if err != nil {
+		return
+	}
+	err = y.interfaceRequest("addface", req, &rsp)
+	return
+}
+
+func (y *Youtu) addFaceReq(personID string, images []Image, tag string) (req addFaceReq, err error) {
+	var dataImages []Image
+	var urls []string
+	for _, img := range images {
+		if img, _, err = y.preprocessImage(img); err != nil {
+			return
+		}
+		data, url, e := img.fields()
+		if e != nil {
+			err = e
+			return
+		}
+		if url != "" {
+			urls = append(urls, url)
+		} else {
+			dataImages = append(dataImages, data)
+		}
 	}
-	req := addFaceReq{
+	req = addFaceReq{
 		AppID:    y.appID(),
-		Images:   b64Images,
+		Images:   dataImages,
+		URLs:     urls,
 		PersonID: personID,
 		Tag:      tag,
 	}
-	err = y.interfaceRequest("addface", req, &rsp)
 	return
 }
 
@@ -371,10 +548,8 @@ type delFaceReq struct {
 
 //DelFaceRsp 删除人脸返回
 type DelFaceRsp struct {
-	SessonID  string `json:"session_id"` //相应请求的session标识符
-	Deleted   int32  `json:"deleted"`    //成功删除的face数量
-	ErrorCode int32  `json:"errorcode"`  //返回状态码
-	ErrorMsg  string `json:"errormsg"`   //返回错误消息
+	Status
+	Deleted int32 `json:"deleted"` //成功删除的face数量
 }
 
 //DelFace 删除一个person下的face，包括特征，属性和face_id.
@@ -397,14 +572,15 @@ type setInfoReq struct {
 
 //SetInfoRsp 设置信息返回
 type SetInfoRsp struct {
-	sessionID string `json:"session_id"` //相应请求的session标识符
-	personID  string `json:"person_id"`  //相应person的id
-	errorcode int32  `json:"errorcode"`  //返回状态码
-	errormsg  string `json:"errormsg"`   //返回错误消息
+	Status
+	PersonID string `json:"person_id"` //相应person的id
 }
 
-//SetInfo 设置Person的name.
+//SetInfo 设置Person的name和tag, 为空的字段不修改
 func (y *Youtu) SetInfo(personID string, personName string, tag string) (rsp SetInfoRsp, err error) {
+	if err = checkTag(tag); err != nil {
+		return
+	}
 	req := setInfoReq{
 		AppID:      y.appID(),
 		PersonID:   personID,
@@ -422,13 +598,12 @@ type getInfoReq struct {
 
 //GetInfoRsp 获取信息返回
 type GetInfoRsp struct {
+	Status
 	PersonName string   `json:"person_name"` //相应person的name
 	PersonID   string   `json:"person_id"`   //相应person的id
 	GroupIDs   []string `json:"group_ids"`   //包含此个体的组列表
 	FaceIDs    []string `json:"face_ids"`    //包含的人脸列表
-	SessionID  string
-	ErrorCode  int    `json:"errorcode"` //返回状态码
-	ErrorMsg   string `json:"errormsg"`  //返回错误消息
+	Tag        string   `json:"tag"`         //备注信息
 }
 
 //GetInfo 获取一个Person的信息, 包括name, id, tag, 相关的face, 以及groups等信息。
@@ -447,9 +622,8 @@ type getGroupIDsReq struct {
 
 //GetGroupIDsRsp 获取组ID返回
 type GetGroupIDsRsp struct {
-	GroupIDs  []string `json:"group_ids"` //相应app_id的group_id列表
-	ErrorCode int32    `json:"errorcode"` //返回状态码
-	ErrorMsg  string   `json:"errormsg"`  //返回错误消息
+	Status
+	GroupIDs []string `json:"group_ids"` //相应app_id的group_id列表
 }
 
 //GetGroupIDs 获取一个appId下所有group列表
@@ -468,9 +642,8 @@ type getPersonIDsReq struct {
 
 //GetPersonIDsRsp 获取个人ID返回
 type GetPersonIDsRsp struct {
+	Status
 	PersonIDs []string `json:"person_ids"` //相应person的id列表
-	ErrorCode int32    `json:"errorcode"`  //返回状态码
-	ErrorMsg  string   `json:"errormsg"`   //返回错误消息
 }
 
 //GetPersonIDs 获取一个组Group中所有person列表
@@ -490,9 +663,8 @@ type getFaceIDsReq struct {
 
 //GetFaceIDsRsp 获取脸ID返回
 type GetFaceIDsRsp struct {
-	FaceIDs   []string `json:"face_ids"`  //相应face的id列表
-	ErrorCode int32    `json:"errorcode"` //返回状态码
-	ErrorMsg  string   `json:"errormsg"`  //返回错误消息
+	Status
+	FaceIDs []string `json:"face_ids"` //相应face的id列表
 }
 
 //GetFaceIDs 获取一个组person中所有face列表
@@ -512,9 +684,8 @@ type getFaceInfoReq struct {
 
 //GetFaceInfoRsp 获取脸部信息返回
 type GetFaceInfoRsp struct {
-	FaceInfo  Face   `json:"face_info"` //人脸信息
-	ErrorCode int32  `json:"errorcode"` //返回状态码
-	ErrorMsg  string `json:"errormsg"`  //返回错误消息
+	Status
+	FaceInfo Face `json:"face_info"` //人脸信息
 }
 
 //GetFaceInfo 获取一个face的相关特征信息
@@ -31,7 +31,7 @@ func TestDetectFace(t *testing.T) {
 		t.Errorf("ReadFile failed: %s", err)
 		return
 	}
-	rsp, err := yt.DetectFace(imgData, false)
+	rsp, err := yt.DetectFace(ImageData(imgData), false)
 	if err != nil {
 		t.Errorf("Detect face faild: %s", err)
 		return
@@ -45,7 +45,7 @@ func TestFaceShape(t *testing.T) {
 		t.Errorf("ReadFile failed: %s\n", err)
 		return
 	}
-	rsp, err := yt.FaceShape(imgData, false)
+	rsp, err := yt.FaceShape(ImageData(imgData), false)
 	if err != nil {
 		t.Errorf("FaceShape failed: %s\n", err)
 		return
@@ -64,7 +64,7 @@ func TestFaceCompare(t *testing.T) {
 		t.Errorf("Encode imageB failed: %s\n", err)
 		return
 	}
-	rsp, err := yt.FaceCompare(imageA, imageB)
+	rsp, err := yt.FaceCompare(ImageData(imageA), ImageData(imageB))
 	if err != nil {
 		t.Errorf("FaceCompare failed: %s\n", err)
 		return
@@ -79,7 +79,7 @@ func TestFaceVerify(t *testing.T) {
 		return
 	}
 	personID := "1045684262752288767"
-	rsp, err := yt.FaceVerify(personID, image)
+	rsp, err := yt.FaceVerify(personID, ImageData(image))
 	if err != nil {
 		t.Errorf("FaceVerify failed: %s\n", err)
 		return
@@ -94,7 +94,7 @@ func TestFaceIdentify(t *testing.T) {
 		return
 	}
 	groupID := "tencent"
-	rsp, err := yt.FaceIdentify(groupID, image)
+	rsp, err := yt.FaceIdentify(groupID, ImageData(image))
 	if err != nil {
 		t.Errorf("FaceIdentify failed: %s\n", err)
 		return
@@ -102,6 +102,36 @@ func TestFaceIdentify(t *testing.T) {
 	t.Logf("rsp: %#v\n", rsp)
 }
 
+func TestFaceIdentifyTopN(t *testing.T) {
+	image, err := ioutil.ReadFile(testDataDir + "imageA.jpg")
+	if err != nil {
+		t.Errorf("ioutil.ReadFile failed: %s\n", err)
+		return
+	}
+	groupIDs := []string{"tencent"}
+	rsp, err := yt.FaceIdentifyTopN(groupIDs, ImageData(image), 5)
+	if err != nil {
+		t.Errorf("FaceIdentifyTopN failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
+func TestMultiFaceIdentify(t *testing.T) {
+	image, err := ioutil.ReadFile(testDataDir + "imageD.jpg")
+	if err != nil {
+		t.Errorf("ioutil.ReadFile failed: %s\n", err)
+		return
+	}
+	groupIDs := []string{"tencent"}
+	rsp, err := yt.MultiFaceIdentify(groupIDs, ImageData(image), 3)
+	if err != nil {
+		t.Errorf("MultiFaceIdentify failed: %s\n", err)
+		return
+	}
+	t.Logf("rsp: %#v\n", rsp)
+}
+
 func TestNewPerson(t *testing.T) {
 	image, err := ioutil.ReadFile(testDataDir + "imageA.jpg")
 	if err != nil {
@@ -109,7 +139,7 @@ func TestNewPerson(t *testing.T) {
 		return
 	}
 	groupIDs := []string{"tencent"}
-	rsp, err := yt.NewPerson("ochapman", "ochapman", groupIDs, image, "person tag")
+	rsp, err := yt.NewPerson("ochapman", "ochapman", groupIDs, ImageData(image), "person tag")
 	if err != nil && rsp.ErrorMsg != "ERROR_PERSON_EXISTED" {
 		t.Errorf("NewPerson failed: %s\n", err)
 		return
@@ -133,7 +163,7 @@ func TestAddFace(t *testing.T) {
 		return
 	}
 	personID := "ochapman"
-	images := [][]byte{image}
+	images := []Image{ImageData(image)}
 	tag := "face tag"
 	rsp, err := yt.AddFace(personID, images, tag)
 	if err != nil {